@@ -1 +1,20 @@
+// 当前包提供基于net/http封装的HTTP服务端功能.
 package qhttp
+
+const (
+	// 默认的服务名称
+	DEFAULT_SERVER = "default"
+	// 默认的监听地址
+	DEFAULT_ADDR = ":80"
+	// 未绑定域名的路由使用的域名名称
+	DEFAULT_DOMAIN = "default"
+)
+
+// 服务状态
+const (
+	SERVER_STATUS_STOPPED = 0
+	SERVER_STATUS_RUNNING = 1
+)
+
+// 请求处理方法
+type HandlerFunc = func(r *Request)
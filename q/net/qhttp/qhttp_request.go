@@ -1,26 +1,127 @@
 package qhttp
 
-import "net/http"
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
 
 // 创建一个请求结构
 type Request struct {
-	*http.Request 	 					 // 内置的http请求方法
-	parsedGet     bool                   // GET参数是否已经解析
-	parsedPost    bool                   // POST参数是否已经解析
-	queryVars     map[string][]string    // GET参数
-	routerVars    map[string][]string    // 路由解析参数
-	exit          bool                   // 是否退出当前请求流程执行
-	Id            int                    // 请求id(唯一)
-	//Server        *Server                // 请求关联的服务器对象
-	//Cookie        *Cookie                // 与当前请求绑定的Cookie对象(并发安全)
-	//Session       *Session               // 与当前请求绑定的Session对象(并发安全)
-	//Response      *Response              // 对应请求的返回数据操作对象
-	//Router        *Router                // 匹配到的路由对象
-	EnterTime     int64                  // 请求进入时间(微秒)
-	LeaveTime     int64                  // 请求完成时间(微秒)
-	params        map[string]interface{} // 开发者自定义参数(请求流程中有效)
-	parsedHost    string                 // 解析过后不带端口号的服务器域名名称
-	clientIp      string                 // 解析过后的客户端IP地址
-	rawContent    []byte                 // 客户端提交的原始参数
-	isFileRequest bool                   // 是否为静态文件请求(非服务请求，当静态文件存在时，优先级会被服务请求高，被识别为文件请求)
-}
\ No newline at end of file
+	*http.Request                          // 内置的http请求方法
+	parsedGet     bool                     // GET参数是否已经解析
+	parsedPost    bool                     // POST参数是否已经解析
+	queryVars     map[string][]string      // GET参数
+	queryMap      map[string]interface{}   // 解析过后的GET参数(支持a[b][c]=1格式)
+	formMap       map[string]interface{}   // 解析过后的表单参数
+	jsonMap       map[string]interface{}   // 解析过后的JSON参数
+	routerVars    map[string][]string      // 路由解析参数
+	exit          bool                     // 是否退出当前请求流程执行
+	inHook        bool                     // 是否正在执行HOOK方法
+	Id            int                      // 请求id(唯一)
+	Server        *Server                  // 请求关联的服务器对象
+	Cookie        *Cookie                  // 与当前请求绑定的Cookie对象(并发安全)
+	Session       *Session                 // 与当前请求绑定的Session对象(并发安全)
+	Response      *Response                // 对应请求的返回数据操作对象
+	Router        *Router                  // 匹配到的路由对象
+	Middleware    *Middleware              // 中间件执行控制对象
+	EnterTime     int64                    // 请求进入时间(微秒)
+	LeaveTime     int64                    // 请求完成时间(微秒)
+	params        map[string]interface{}   // 开发者自定义参数(请求流程中有效)
+	parsedHost    string                   // 解析过后不带端口号的服务器域名名称
+	clientIp      string                   // 解析过后的客户端IP地址
+	rawContent    []byte                   // 客户端提交的原始参数
+	uploadFiles   map[string][]*UploadFile // 上传的文件，按照表单名称保存
+	isFileRequest bool                     // 是否为静态文件请求(非服务请求，当静态文件存在时，优先级会被服务请求高，被识别为文件请求)
+	hooks         []*handlerItem           // 请求匹配的HOOK方法，为nil时表示还未检索
+	statusHandled bool                     // 状态码处理方法或者错误处理方法是否已经执行
+	csrf          *CSRFConfig              // CSRF中间件设置的防护配置
+}
+
+// 请求id生成器，进程内唯一递增
+var requestIdSeq int64
+
+// 用于中断请求流程的异常类型，使用私有类型避免与其他代码panic的值混淆
+type exitSignal int
+
+// 用于中断请求流程的异常
+const (
+	exceptionExit     exitSignal = iota // 退出当前处理方法
+	exceptionExitAll                    // 退出当前请求流程
+	exceptionExitHook                   // 退出当前HOOK点
+)
+
+// 异常的名称，用于在日志中输出
+func (e exitSignal) String() string {
+	switch e {
+	case exceptionExitAll:
+		return "exit_all"
+	case exceptionExitHook:
+		return "exit_hook"
+	}
+	return "exit"
+}
+
+// 创建一个请求对象
+func newRequest(s *Server, r *http.Request, w http.ResponseWriter) *Request {
+	request := &Request{
+		Request:   r,
+		Id:        int(atomic.AddInt64(&requestIdSeq, 1)),
+		Server:    s,
+		Response:  newResponse(s, w),
+		EnterTime: time.Now().UnixNano() / 1000,
+	}
+	request.Response.request = request
+	request.parsedHost = parseHost(r.Host)
+	if s.config.ClientMaxBodySize > 0 && r.Body != nil {
+		r.Body = http.MaxBytesReader(w, r.Body, s.config.ClientMaxBodySize)
+	}
+	request.Cookie = newCookie(request)
+	request.Session = newSession(request)
+	request.Response.Writer.onWriteHeader(request.Cookie.flush)
+	return request
+}
+
+// 解析不带端口号的域名名称，例如: a.com:8080 => a.com、[::1]:80 => ::1
+func parseHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(strings.Trim(host, "[]"))
+}
+
+// 获取解析过后不带端口号的域名名称
+func (r *Request) GetHost() string {
+	return r.parsedHost
+}
+
+// 判断当前请求是否为静态文件请求
+func (r *Request) IsFileRequest() bool {
+	return r.isFileRequest
+}
+
+// 退出当前处理方法的执行，已经写入的返回内容会被保留
+func (r *Request) Exit() {
+	panic(exceptionExit)
+}
+
+// 退出当前请求流程，当前以及后续所有的处理方法都不再执行，已经写入的返回内容会被保留
+func (r *Request) ExitAll() {
+	r.exit = true
+	panic(exceptionExitAll)
+}
+
+// 退出当前HOOK点的执行，同一HOOK点后续的HOOK方法不再执行，在HOOK方法之外调用时等同于Exit
+func (r *Request) ExitHook() {
+	if !r.inHook {
+		panic(exceptionExit)
+	}
+	panic(exceptionExitHook)
+}
+
+// 当前请求流程是否已经退出
+func (r *Request) IsExited() bool {
+	return r.exit
+}
@@ -0,0 +1,150 @@
+package qhttp
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Cookie对象，与请求绑定，请求中的Cookie在第一次访问时解析，
+// 修改过的Cookie在返回头输出时统一写入，方法都是并发安全的
+type Cookie struct {
+	mu      sync.RWMutex           // 并发控制
+	data    map[string]*cookieItem // Cookie数据，为nil时表示还未解析
+	request *Request               // 关联的请求对象
+}
+
+// Cookie数据项
+type cookieItem struct {
+	*http.Cookie
+	changed bool // 是否在当前请求中被修改，修改过的Cookie才会输出
+}
+
+// 创建一个Cookie对象
+func newCookie(r *Request) *Cookie {
+	return &Cookie{
+		request: r,
+	}
+}
+
+// 解析请求中的Cookie，只会执行一次，调用方需要持有写锁
+func (c *Cookie) init() {
+	if c.data != nil {
+		return
+	}
+	c.data = make(map[string]*cookieItem)
+	for _, v := range c.request.Cookies() {
+		c.data[v.Name] = &cookieItem{Cookie: v}
+	}
+}
+
+// 获取所有Cookie的键值对，已删除的Cookie不包含在内
+func (c *Cookie) Map() map[string]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.init()
+	m := make(map[string]string, len(c.data))
+	for k, v := range c.data {
+		if v.MaxAge >= 0 {
+			m[k] = v.Value
+		}
+	}
+	return m
+}
+
+// 判断Cookie是否存在
+func (c *Cookie) Contains(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.init()
+	v, ok := c.data[key]
+	return ok && v.MaxAge >= 0
+}
+
+// 获取Cookie的值，不存在时返回<def>
+func (c *Cookie) Get(key string, def ...string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.init()
+	if v, ok := c.data[key]; ok && v.MaxAge >= 0 {
+		return v.Value
+	}
+	return defaultString(def)
+}
+
+// 使用默认的域名、路径以及有效期设置Cookie
+func (c *Cookie) Set(key, value string) {
+	config := c.request.Server.config
+	c.SetCookie(key, value, c.defaultDomain(), config.CookiePath, config.CookieMaxAge, false, false)
+}
+
+// 设置Cookie，<maxAge>为0时表示会话Cookie，<sameSite>为空时不设置SameSite属性
+func (c *Cookie) SetCookie(key, value, domain, path string, maxAge time.Duration, httpOnly, secure bool, sameSite ...http.SameSite) {
+	cookie := &http.Cookie{
+		Name:     key,
+		Value:    value,
+		Domain:   domain,
+		Path:     path,
+		HttpOnly: httpOnly,
+		Secure:   secure,
+	}
+	if maxAge > 0 {
+		cookie.MaxAge = int(maxAge / time.Second)
+		cookie.Expires = time.Now().Add(maxAge)
+	}
+	if len(sameSite) > 0 {
+		cookie.SameSite = sameSite[0]
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.init()
+	c.data[key] = &cookieItem{Cookie: cookie, changed: true}
+}
+
+// 使用默认的域名以及路径删除Cookie
+func (c *Cookie) Remove(key string) {
+	c.RemoveCookie(key, c.defaultDomain(), c.request.Server.config.CookiePath)
+}
+
+// 删除指定域名以及路径的Cookie
+func (c *Cookie) RemoveCookie(key, domain, path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.init()
+	c.data[key] = &cookieItem{
+		Cookie: &http.Cookie{
+			Name:    key,
+			Domain:  domain,
+			Path:    path,
+			MaxAge:  -1,
+			Expires: time.Unix(0, 0),
+		},
+		changed: true,
+	}
+}
+
+// 获取Cookie的默认域名，未配置时使用请求的域名
+func (c *Cookie) defaultDomain() string {
+	if domain := c.request.Server.config.CookieDomain; domain != "" {
+		return domain
+	}
+	return c.request.parsedHost
+}
+
+// 将修改过的Cookie按照名称顺序写入返回头
+func (c *Cookie) flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	keys := make([]string, 0)
+	for k, v := range c.data {
+		if v.changed {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		http.SetCookie(c.request.Response.Writer, c.data[k].Cookie)
+		c.data[k].changed = false
+	}
+}
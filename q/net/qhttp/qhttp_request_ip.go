@@ -0,0 +1,120 @@
+package qhttp
+
+import (
+	"net"
+	"strings"
+)
+
+// 获取客户端IP，只有当直连地址属于可信代理时才会依次解析Forwarded、X-Forwarded-For以及X-Real-IP请求头，
+// 代理链从右往左查找第一个非可信代理的地址，请求头格式错误时返回直连地址
+func (r *Request) GetClientIp() string {
+	if r.clientIp != "" {
+		return r.clientIp
+	}
+	r.clientIp = r.GetRemoteIp()
+	remote := net.ParseIP(r.clientIp)
+	if remote == nil || !r.Server.isTrustedProxy(remote) {
+		return r.clientIp
+	}
+	var (
+		chain []net.IP
+		ok    bool
+	)
+	if v := r.Header.Values("Forwarded"); len(v) > 0 {
+		chain, ok = parseForwarded(strings.Join(v, ","))
+	} else if v := r.Header.Values("X-Forwarded-For"); len(v) > 0 {
+		chain, ok = parseForwardedFor(strings.Join(v, ","))
+	} else if v := r.Header.Get("X-Real-IP"); v != "" {
+		var ip net.IP
+		if ip = net.ParseIP(strings.TrimSpace(v)); ip != nil {
+			chain, ok = []net.IP{ip}, true
+		}
+	}
+	if !ok || len(chain) == 0 {
+		return r.clientIp
+	}
+	// 从右往左跳过可信代理，全部为可信代理时使用最左边的地址
+	client := chain[0]
+	for i := len(chain) - 1; i >= 0; i-- {
+		if !r.Server.isTrustedProxy(chain[i]) {
+			client = chain[i]
+			break
+		}
+	}
+	r.clientIp = client.String()
+	return r.clientIp
+}
+
+// 获取直连的客户端地址(不带端口号)，经过代理时为代理的地址
+func (r *Request) GetRemoteIp() string {
+	host := r.RemoteAddr
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.Trim(host, "[]")
+}
+
+// 解析X-Forwarded-For请求头，格式: client, proxy1, proxy2
+func parseForwardedFor(value string) ([]net.IP, bool) {
+	items := strings.Split(value, ",")
+	chain := make([]net.IP, 0, len(items))
+	for _, v := range items {
+		ip := net.ParseIP(strings.TrimSpace(v))
+		if ip == nil {
+			return nil, false
+		}
+		chain = append(chain, ip)
+	}
+	return chain, true
+}
+
+// 解析RFC 7239中的Forwarded请求头，格式: for=192.0.2.60;proto=http, for="[2001:db8::1]:4711"，
+// 未知(unknown)以及混淆的节点标识无法确定地址，按照格式错误处理
+func parseForwarded(value string) ([]net.IP, bool) {
+	chain := make([]net.IP, 0)
+	for _, element := range strings.Split(value, ",") {
+		var ip net.IP
+		for _, pair := range strings.Split(element, ";") {
+			kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+			if len(kv) != 2 {
+				if kv[0] == "" {
+					continue
+				}
+				return nil, false
+			}
+			if !strings.EqualFold(strings.TrimSpace(kv[0]), "for") {
+				continue
+			}
+			if ip = parseForwardedNode(kv[1]); ip == nil {
+				return nil, false
+			}
+		}
+		if ip == nil {
+			return nil, false
+		}
+		chain = append(chain, ip)
+	}
+	return chain, true
+}
+
+// 解析Forwarded请求头中的节点标识，例如: 192.0.2.43、"192.0.2.43:47011"、"[2001:db8::1]"
+func parseForwardedNode(node string) net.IP {
+	node = strings.TrimSpace(node)
+	if len(node) >= 2 && node[0] == '"' && node[len(node)-1] == '"' {
+		node = node[1 : len(node)-1]
+	}
+	if strings.HasPrefix(node, "[") {
+		end := strings.Index(node, "]")
+		if end < 0 || (end != len(node)-1 && node[end+1] != ':') {
+			return nil
+		}
+		return net.ParseIP(node[1:end])
+	}
+	if ip := net.ParseIP(node); ip != nil {
+		return ip
+	}
+	if h, _, err := net.SplitHostPort(node); err == nil {
+		return net.ParseIP(h)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package qhttp
+
+// 中间件执行控制对象，请求匹配的中间件以及处理方法按照以下顺序组成执行链:
+// 全局中间件(Use) > 分组中间件(外层分组优先) > 路由中间件(BindMiddleware) > 处理方法，
+// 同一级别的中间件按照注册顺序执行。
+type Middleware struct {
+	request  *Request      // 关联的请求对象
+	handlers []HandlerFunc // 执行链
+	index    int           // 下一个需要执行的位置
+}
+
+// 执行执行链中的下一个中间件或者处理方法，
+// 中间件中调用Next之前的代码在处理方法之前执行，之后的代码在处理方法之后执行，
+// 不调用Next时后续的中间件以及处理方法不会被执行。
+func (m *Middleware) Next() {
+	if m.request.exit || m.index >= len(m.handlers) {
+		return
+	}
+	handler := m.handlers[m.index]
+	m.index++
+	niceCallHandler(handler, m.request)
+}
+
+// 添加全局中间件，对所有匹配到路由的请求生效
+func (s *Server) Use(handlers ...HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.middleware = append(s.middleware, handlers...)
+}
+
+// 按照路由规则绑定中间件，规则格式与BindHandler一致，例如: /api/*
+func (s *Server) BindMiddleware(pattern string, handlers ...HandlerFunc) {
+	for _, handler := range handlers {
+		if err := s.addItem(pattern, &handlerItem{
+			itemType: handlerTypeMiddleware,
+			handler:  handler,
+		}); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// 生成请求的执行链
+func (s *Server) buildHandlers(r *Request, item *handlerItem) []HandlerFunc {
+	s.mu.RLock()
+	handlers := make([]HandlerFunc, 0, len(s.middleware)+len(item.middleware)+1)
+	handlers = append(handlers, s.middleware...)
+	s.mu.RUnlock()
+	handlers = append(handlers, item.middleware...)
+	for _, v := range s.searchItems(handlerTypeMiddleware, r.Method, r.URL.Path, r.parsedHost) {
+		handlers = append(handlers, v.handler)
+	}
+	return append(handlers, item.handler)
+}
@@ -0,0 +1,410 @@
+package qhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/url"
+	"strings"
+
+	"grt/q/utils/conv"
+)
+
+// 获取客户端提交的原始数据，数据会被缓存，并且请求的Body可以被再次读取
+func (r *Request) GetRaw() []byte {
+	if r.rawContent == nil {
+		r.rawContent = []byte{}
+		if r.Body != nil {
+			data, err := ioutil.ReadAll(r.Body)
+			r.Body.Close()
+			if err == nil {
+				r.rawContent = data
+			} else if isBodyTooLarge(err) {
+				r.Body = ioutil.NopCloser(bytes.NewReader(r.rawContent))
+				r.exitTooLarge()
+			}
+		}
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(r.rawContent))
+	return r.rawContent
+}
+
+// 获取客户端提交的原始数据字符串
+func (r *Request) GetRawString() string {
+	return string(r.GetRaw())
+}
+
+// 获取GET参数，参数不存在时返回默认值<def>
+func (r *Request) GetQuery(key string, def ...interface{}) interface{} {
+	r.parseQuery()
+	if v, ok := r.queryMap[key]; ok {
+		return v
+	}
+	return defaultValue(def)
+}
+
+// 获取GET参数的字符串值
+func (r *Request) GetQueryString(key string, def ...string) string {
+	if v := r.GetQuery(key); v != nil {
+		return conv.String(v)
+	}
+	return defaultString(def)
+}
+
+// 获取GET参数的int值
+func (r *Request) GetQueryInt(key string, def ...int) int {
+	if v := r.GetQuery(key); v != nil {
+		return conv.Int(v)
+	}
+	return defaultInt(def)
+}
+
+// 获取所有的GET参数
+func (r *Request) GetQueryMap() map[string]interface{} {
+	r.parseQuery()
+	return copyMap(r.queryMap)
+}
+
+// 获取表单参数(application/x-www-form-urlencoded或者multipart/form-data)
+func (r *Request) GetForm(key string, def ...interface{}) interface{} {
+	r.parseBody()
+	if v, ok := r.formMap[key]; ok {
+		return v
+	}
+	return defaultValue(def)
+}
+
+// 获取表单参数的字符串值
+func (r *Request) GetFormString(key string, def ...string) string {
+	if v := r.GetForm(key); v != nil {
+		return conv.String(v)
+	}
+	return defaultString(def)
+}
+
+// 获取所有的表单参数
+func (r *Request) GetFormMap() map[string]interface{} {
+	r.parseBody()
+	return copyMap(r.formMap)
+}
+
+// 获取JSON格式提交的参数，只有提交内容为JSON对象时才会被解析为参数
+func (r *Request) GetJson(key string, def ...interface{}) interface{} {
+	r.parseBody()
+	if v, ok := r.jsonMap[key]; ok {
+		return v
+	}
+	return defaultValue(def)
+}
+
+// 获取所有的JSON参数
+func (r *Request) GetJsonMap() map[string]interface{} {
+	r.parseBody()
+	return copyMap(r.jsonMap)
+}
+
+// 获取POST参数，包括表单参数以及JSON参数，同名时表单参数优先
+func (r *Request) GetPost(key string, def ...interface{}) interface{} {
+	r.parseBody()
+	if v, ok := r.formMap[key]; ok {
+		return v
+	}
+	if v, ok := r.jsonMap[key]; ok {
+		return v
+	}
+	return defaultValue(def)
+}
+
+// 获取POST参数的字符串值
+func (r *Request) GetPostString(key string, def ...string) string {
+	if v := r.GetPost(key); v != nil {
+		return conv.String(v)
+	}
+	return defaultString(def)
+}
+
+// 获取POST参数的int值
+func (r *Request) GetPostInt(key string, def ...int) int {
+	if v := r.GetPost(key); v != nil {
+		return conv.Int(v)
+	}
+	return defaultInt(def)
+}
+
+// 获取所有的POST参数
+func (r *Request) GetPostMap() map[string]interface{} {
+	r.parseBody()
+	m := copyMap(r.jsonMap)
+	for k, v := range r.formMap {
+		m[k] = v
+	}
+	return m
+}
+
+// 获取请求参数，同名参数的优先级为: 路由参数 > POST参数(表单 > JSON) > GET参数
+func (r *Request) GetRequest(key string, def ...interface{}) interface{} {
+	if v, ok := r.routerVars[key]; ok && len(v) > 0 {
+		return v[0]
+	}
+	if v := r.GetPost(key); v != nil {
+		return v
+	}
+	if v := r.GetQuery(key); v != nil {
+		return v
+	}
+	return defaultValue(def)
+}
+
+// 获取所有的请求参数，同名参数的优先级与GetRequest一致
+func (r *Request) GetRequestMap() map[string]interface{} {
+	m := r.GetQueryMap()
+	for k, v := range r.GetPostMap() {
+		m[k] = v
+	}
+	for k, v := range r.routerVars {
+		if len(v) > 0 {
+			m[k] = v[0]
+		}
+	}
+	return m
+}
+
+// 获取请求参数的字符串值
+func (r *Request) GetString(key string, def ...string) string {
+	if v := r.GetRequest(key); v != nil {
+		return conv.String(v)
+	}
+	return defaultString(def)
+}
+
+// 获取请求参数的int值
+func (r *Request) GetInt(key string, def ...int) int {
+	if v := r.GetRequest(key); v != nil {
+		return conv.Int(v)
+	}
+	return defaultInt(def)
+}
+
+// 获取请求参数的int64值
+func (r *Request) GetInt64(key string, def ...int64) int64 {
+	if v := r.GetRequest(key); v != nil {
+		return conv.Int64(v)
+	}
+	if len(def) > 0 {
+		return def[0]
+	}
+	return 0
+}
+
+// 获取请求参数的uint值
+func (r *Request) GetUint(key string, def ...uint) uint {
+	if v := r.GetRequest(key); v != nil {
+		return conv.Uint(v)
+	}
+	if len(def) > 0 {
+		return def[0]
+	}
+	return 0
+}
+
+// 获取请求参数的float64值
+func (r *Request) GetFloat64(key string, def ...float64) float64 {
+	if v := r.GetRequest(key); v != nil {
+		return conv.Float64(v)
+	}
+	if len(def) > 0 {
+		return def[0]
+	}
+	return 0
+}
+
+// 获取请求参数的bool值
+func (r *Request) GetBool(key string, def ...bool) bool {
+	if v := r.GetRequest(key); v != nil {
+		return conv.Bool(v)
+	}
+	if len(def) > 0 {
+		return def[0]
+	}
+	return false
+}
+
+// 获取请求参数的[]string值，例如: a=1&a=2 或者 a[]=1&a[]=2
+func (r *Request) GetStrings(key string, def ...[]string) []string {
+	if v := r.GetRequest(key); v != nil {
+		return conv.Strings(v)
+	}
+	if len(def) > 0 {
+		return def[0]
+	}
+	return nil
+}
+
+// 获取请求参数的map值，例如: a[b]=1&a[c]=2 或者JSON对象
+func (r *Request) GetMap(key string, def ...map[string]interface{}) map[string]interface{} {
+	if v := r.GetRequest(key); v != nil {
+		if m := conv.Map(v); m != nil {
+			return m
+		}
+	}
+	if len(def) > 0 {
+		return def[0]
+	}
+	return nil
+}
+
+// 解析GET参数，只会解析一次
+func (r *Request) parseQuery() {
+	if r.parsedGet {
+		return
+	}
+	r.parsedGet = true
+	r.queryVars, _ = url.ParseQuery(r.URL.RawQuery)
+	r.queryMap = parseNestedVars(r.queryVars)
+}
+
+// 解析POST参数，只会在第一次获取参数时解析一次
+func (r *Request) parseBody() {
+	if r.parsedPost {
+		return
+	}
+	r.parsedPost = true
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch {
+	case contentType == "multipart/form-data":
+		r.parseMultipart()
+
+	case contentType == "application/x-www-form-urlencoded":
+		if values, err := url.ParseQuery(r.GetRawString()); err == nil {
+			r.formMap = parseNestedVars(values)
+		}
+
+	case strings.Contains(contentType, "json"):
+		r.jsonMap = parseJsonObject(r.GetRaw())
+
+	case contentType == "":
+		// 未指定类型时根据内容判断是JSON对象还是表单
+		raw := bytes.TrimSpace(r.GetRaw())
+		if len(raw) > 0 && raw[0] == '{' {
+			r.jsonMap = parseJsonObject(raw)
+		} else if values, err := url.ParseQuery(string(raw)); err == nil {
+			r.formMap = parseNestedVars(values)
+		}
+	}
+}
+
+// 将JSON对象解析为map，数字使用json.Number保持精度
+func parseJsonObject(data []byte) map[string]interface{} {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	m := make(map[string]interface{})
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&m); err != nil && err != io.EOF {
+		return nil
+	}
+	return m
+}
+
+// 解析PHP风格的参数，例如:
+// a=1&a=2       => {"a": ["1", "2"]}
+// a[]=1&a[]=2   => {"a": ["1", "2"]}
+// a[b][c]=1     => {"a": {"b": {"c": "1"}}}
+// a[][b]=1      => {"a": [{"b": "1"}]}
+func parseNestedVars(values map[string][]string) map[string]interface{} {
+	m := make(map[string]interface{}, len(values))
+	for key, array := range values {
+		path := parseNestedKey(key)
+		if len(path) == 1 {
+			if len(array) == 1 {
+				m[key] = array[0]
+			} else {
+				items := make([]interface{}, len(array))
+				for i, v := range array {
+					items[i] = v
+				}
+				m[key] = items
+			}
+			continue
+		}
+		for _, v := range array {
+			m[path[0]] = setNestedValue(m[path[0]], path[1:], v)
+		}
+	}
+	return m
+}
+
+// 将参数名称解析为路径，例如: a[b][c] => [a b c]，格式不正确时作为普通参数名称
+func parseNestedKey(key string) []string {
+	index := strings.IndexByte(key, '[')
+	if index <= 0 || !strings.HasSuffix(key, "]") {
+		return []string{key}
+	}
+	path := []string{key[:index]}
+	for rest := key[index:]; rest != ""; {
+		end := strings.IndexByte(rest, ']')
+		if rest[0] != '[' || end == -1 {
+			return []string{key}
+		}
+		path = append(path, rest[1:end])
+		rest = rest[end+1:]
+	}
+	return path
+}
+
+// 按照路径将值设置到嵌套的参数中，空的路径名称表示追加到数组
+func setNestedValue(node interface{}, path []string, value string) interface{} {
+	if path[0] == "" {
+		array, _ := node.([]interface{})
+		if len(path) == 1 {
+			return append(array, value)
+		}
+		return append(array, setNestedValue(nil, path[1:], value))
+	}
+	m, ok := node.(map[string]interface{})
+	if !ok {
+		m = make(map[string]interface{})
+	}
+	if len(path) == 1 {
+		m[path[0]] = value
+	} else {
+		m[path[0]] = setNestedValue(m[path[0]], path[1:], value)
+	}
+	return m
+}
+
+// 复制参数map，避免外部修改影响请求中缓存的参数
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// 获取可选的默认值
+func defaultValue(def []interface{}) interface{} {
+	if len(def) > 0 {
+		return def[0]
+	}
+	return nil
+}
+
+// 获取可选的字符串默认值
+func defaultString(def []string) string {
+	if len(def) > 0 {
+		return def[0]
+	}
+	return ""
+}
+
+// 获取可选的int默认值
+func defaultInt(def []int) int {
+	if len(def) > 0 {
+		return def[0]
+	}
+	return 0
+}
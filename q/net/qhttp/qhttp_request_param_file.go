@@ -0,0 +1,229 @@
+package qhttp
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"grt/q/utils/random"
+)
+
+// 上传文件超出大小限制
+var errUploadTooLarge = errors.New("qhttp: upload file too large")
+
+// 客户端上传的文件，文件内容在解析表单时写入临时文件，请求结束后未保存的临时文件会被删除
+type UploadFile struct {
+	Filename string               // 客户端提交的文件名称
+	Header   textproto.MIMEHeader // 文件在表单中的头信息
+	Size     int64                // 文件大小(字节)
+	path     string               // 文件路径，保存之前为临时文件路径
+	temp     bool                 // 文件是否为请求结束时需要删除的临时文件
+}
+
+// 获取上传文件的Content-Type
+func (f *UploadFile) ContentType() string {
+	return f.Header.Get("Content-Type")
+}
+
+// 打开上传的文件读取内容
+func (f *UploadFile) Open() (*os.File, error) {
+	return os.Open(f.path)
+}
+
+// 将上传的文件保存到目录<dir>中，目录不存在时自动创建，返回保存的文件名称。
+// 默认使用客户端提交的文件名称，<randomName>为true时使用随机文件名称并保留扩展名。
+// 目录中已经存在同名文件时返回错误(可以使用os.IsExist判断)，不会覆盖已有的文件。
+func (f *UploadFile) Save(dir string, randomName ...bool) (filename string, err error) {
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	filename = uploadFileName(f.Filename)
+	if filename == "" || (len(randomName) > 0 && randomName[0]) {
+		filename = strings.ToLower(strconv.FormatInt(time.Now().UnixNano(), 36)+random.Str(6)) +
+			strings.ToLower(filepath.Ext(filename))
+	}
+	dst := filepath.Join(dir, filename)
+	// 以独占方式创建目标文件，避免覆盖其他请求保存的同名文件
+	file, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return "", err
+	}
+	// 第一次保存时直接移动临时文件替换创建的空文件，跨设备等原因移动失败时复制文件内容
+	if f.temp {
+		file.Close()
+		if err = os.Rename(f.path, dst); err == nil {
+			f.path, f.temp = dst, false
+			return filename, os.Chmod(dst, 0644)
+		}
+		if file, err = os.OpenFile(dst, os.O_WRONLY|os.O_TRUNC, 0644); err != nil {
+			os.Remove(dst)
+			return "", err
+		}
+	}
+	src, err := os.Open(f.path)
+	if err == nil {
+		_, err = io.Copy(file, src)
+		src.Close()
+	}
+	if e := file.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		os.Remove(dst)
+		return "", err
+	}
+	return filename, nil
+}
+
+// 去掉客户端文件名称中的路径，避免保存到目录之外，例如: ../../a.txt => a.txt、C:\a.txt => a.txt
+func uploadFileName(name string) string {
+	name = path.Base(strings.Replace(name, "\\", "/", -1))
+	if name == "." || name == ".." || name == "/" {
+		return ""
+	}
+	return name
+}
+
+// 获取表单中名称为<name>的上传文件，有多个文件时返回第一个，不存在时返回nil
+func (r *Request) GetUploadFile(name string) *UploadFile {
+	if files := r.GetUploadFiles(name); len(files) > 0 {
+		return files[0]
+	}
+	return nil
+}
+
+// 获取表单中名称为<name>的所有上传文件，名称可以省略"[]"后缀，例如: files[] => files
+func (r *Request) GetUploadFiles(name string) []*UploadFile {
+	r.parseBody()
+	if files, ok := r.uploadFiles[name]; ok {
+		return files
+	}
+	return r.uploadFiles[name+"[]"]
+}
+
+// 流式解析multipart表单，普通字段保存在内存中，上传文件逐个写入临时文件
+func (r *Request) parseMultipart() {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return
+	}
+	values := make(map[string][]string)
+	defer func() {
+		r.formMap = parseNestedVars(values)
+	}()
+	memory, limited := r.Server.config.FormParsingMemory, r.Server.config.FormParsingMemory > 0
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			if isBodyTooLarge(err) {
+				r.exitTooLarge()
+			}
+			return
+		}
+		name := part.FormName()
+		if name == "" {
+			part.Close()
+			continue
+		}
+		if part.FileName() == "" {
+			body := io.Reader(part)
+			if limited {
+				body = io.LimitReader(part, memory+1)
+			}
+			data, err := ioutil.ReadAll(body)
+			part.Close()
+			if limited {
+				if memory -= int64(len(data)); memory < 0 {
+					err = errUploadTooLarge
+				}
+			}
+			if err != nil {
+				if isBodyTooLarge(err) {
+					r.exitTooLarge()
+				}
+				return
+			}
+			values[name] = append(values[name], string(data))
+			continue
+		}
+		file, err := r.saveUploadFile(part)
+		part.Close()
+		if file != nil {
+			if r.uploadFiles == nil {
+				r.uploadFiles = make(map[string][]*UploadFile)
+			}
+			r.uploadFiles[name] = append(r.uploadFiles[name], file)
+		}
+		if err != nil {
+			if isBodyTooLarge(err) {
+				r.exitTooLarge()
+			}
+			return
+		}
+	}
+}
+
+// 将上传的文件内容写入临时文件，写入失败时返回的文件对象仍然需要在请求结束时清理
+func (r *Request) saveUploadFile(part *multipart.Part) (*UploadFile, error) {
+	temp, err := ioutil.TempFile("", "qhttp-upload-")
+	if err != nil {
+		return nil, err
+	}
+	file := &UploadFile{
+		Filename: part.FileName(),
+		Header:   part.Header,
+		path:     temp.Name(),
+		temp:     true,
+	}
+	max := r.Server.config.UploadMaxFileSize
+	file.Size, err = io.Copy(temp, limitReader(part, max))
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && max > 0 && file.Size > max {
+		err = errUploadTooLarge
+	}
+	return file, err
+}
+
+// 删除请求中未保存的上传临时文件
+func (r *Request) removeUploadFiles() {
+	for _, files := range r.uploadFiles {
+		for _, f := range files {
+			if f.temp {
+				os.Remove(f.path)
+				f.temp = false
+			}
+		}
+	}
+}
+
+// 返回最多读取<max>+1字节的Reader，用于判断内容是否超出限制，<max>小于等于0时不限制
+func limitReader(reader io.Reader, max int64) io.Reader {
+	if max <= 0 {
+		return reader
+	}
+	return io.LimitReader(reader, max+1)
+}
+
+// 判断错误是否为请求内容或者上传文件超出大小限制
+func isBodyTooLarge(err error) bool {
+	var maxErr *http.MaxBytesError
+	return err == errUploadTooLarge || errors.As(err, &maxErr)
+}
+
+// 请求内容超出大小限制时返回413并退出当前请求流程
+func (r *Request) exitTooLarge() {
+	r.Response.ClearBuffer()
+	r.Response.WriteStatus(http.StatusRequestEntityTooLarge)
+	r.ExitAll()
+}
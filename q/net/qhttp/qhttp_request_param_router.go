@@ -0,0 +1,39 @@
+package qhttp
+
+// 获取路由解析参数，参数不存在时返回默认值<def>
+func (r *Request) GetRouterValue(key string, def ...interface{}) interface{} {
+	if v, ok := r.routerVars[key]; ok && len(v) > 0 {
+		return v[0]
+	}
+	if len(def) > 0 {
+		return def[0]
+	}
+	return nil
+}
+
+// 获取路由解析参数的字符串值
+func (r *Request) GetRouterString(key string, def ...string) string {
+	if v, ok := r.routerVars[key]; ok && len(v) > 0 {
+		return v[0]
+	}
+	if len(def) > 0 {
+		return def[0]
+	}
+	return ""
+}
+
+// 获取路由解析参数的所有值，同名参数在规则中出现多次时会有多个值
+func (r *Request) GetRouterArray(key string) []string {
+	return r.routerVars[key]
+}
+
+// 获取所有的路由解析参数，同名参数只返回第一个值
+func (r *Request) GetRouterMap() map[string]string {
+	m := make(map[string]string, len(r.routerVars))
+	for k, v := range r.routerVars {
+		if len(v) > 0 {
+			m[k] = v[0]
+		}
+	}
+	return m
+}
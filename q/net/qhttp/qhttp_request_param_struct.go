@@ -0,0 +1,48 @@
+package qhttp
+
+import (
+	"net/http"
+
+	"grt/q/utils/conv"
+	"grt/q/utils/valid"
+)
+
+// 结构体属性中指定参数名称的标签，例如: `p:"name"`
+const STRUCT_TAG_PARAM = "p"
+
+// 将所有请求参数(路由参数、POST参数以及GET参数)绑定到结构体指针<pointer>中，
+// 参数名称优先使用p标签，其次为属性名称，最后忽略大小写进行匹配，
+// 转换失败的属性通过conv.StructError返回。
+func (r *Request) GetStruct(pointer interface{}) error {
+	return conv.Struct(r.GetRequestMap(), pointer, STRUCT_TAG_PARAM)
+}
+
+// 将GET参数绑定到结构体指针<pointer>中
+func (r *Request) GetQueryStruct(pointer interface{}) error {
+	return conv.Struct(r.GetQueryMap(), pointer, STRUCT_TAG_PARAM)
+}
+
+// 将POST参数绑定到结构体指针<pointer>中
+func (r *Request) GetPostStruct(pointer interface{}) error {
+	return conv.Struct(r.GetPostMap(), pointer, STRUCT_TAG_PARAM)
+}
+
+// 解析请求参数到结构体指针<pointer>中，并按照属性的v标签进行校验，
+// 转换失败时返回conv.StructError，校验失败时返回*valid.Error。
+func (r *Request) Parse(pointer interface{}) error {
+	if err := r.GetStruct(pointer); err != nil {
+		return err
+	}
+	if err := valid.CheckStruct(pointer, r.GetRequestMap()); err != nil {
+		return err
+	}
+	return nil
+}
+
+// 解析并校验请求参数，失败时返回400状态码以及错误信息，并退出当前请求流程
+func (r *Request) ParseOrExit(pointer interface{}) {
+	if err := r.Parse(pointer); err != nil {
+		r.Response.WriteStatus(http.StatusBadRequest, err.Error())
+		r.ExitAll()
+	}
+}
@@ -0,0 +1,473 @@
+package qhttp
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// WebSocket消息类型
+const (
+	WS_MSG_TEXT   = 1  // 文本消息
+	WS_MSG_BINARY = 2  // 二进制消息
+	WS_MSG_CLOSE  = 8  // 关闭消息
+	WS_MSG_PING   = 9  // ping消息
+	WS_MSG_PONG   = 10 // pong消息
+)
+
+// WebSocket关闭状态码
+const (
+	WS_CLOSE_NORMAL          = 1000 // 正常关闭
+	WS_CLOSE_GOING_AWAY      = 1001 // 服务端或者客户端离开
+	WS_CLOSE_PROTOCOL_ERROR  = 1002 // 协议错误
+	WS_CLOSE_UNSUPPORTED     = 1003 // 不支持的消息类型
+	WS_CLOSE_NO_STATUS       = 1005 // 关闭消息中没有状态码
+	WS_CLOSE_ABNORMAL        = 1006 // 连接异常断开
+	WS_CLOSE_INVALID_PAYLOAD = 1007 // 消息内容与类型不一致
+	WS_CLOSE_POLICY          = 1008 // 违反策略
+	WS_CLOSE_TOO_BIG         = 1009 // 消息过大
+	WS_CLOSE_INTERNAL        = 1011 // 服务端内部错误
+)
+
+// 用于计算Sec-WebSocket-Accept的GUID
+const wsAcceptGuid = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// 压缩数据的结束标记，压缩时去掉，解压时补充
+const wsDeflateTail = "\x00\x00\xff\xff"
+
+// 单个数据帧的最大长度，没有设置消息长度限制时同样生效
+const wsMaxFrameSize = 1 << 30
+
+// 读取数据帧时预先分配的最大内存，超出部分按照实际收到的数据增长
+const wsFrameBufferSize = 64 * 1024
+
+// 连接已经关闭
+var ErrWebSocketClosed = errors.New("websocket: connection closed")
+
+// 收到关闭消息或者因为协议错误关闭连接时返回的错误
+type WebSocketCloseError struct {
+	Code int    // 关闭状态码
+	Text string // 关闭原因
+}
+
+func (e *WebSocketCloseError) Error() string {
+	return fmt.Sprintf("websocket: close %d %s", e.Code, e.Text)
+}
+
+// WebSocket连接，同一时间只能有一个协程读取消息，写入消息是并发安全的
+type WebSocket struct {
+	conn        net.Conn          // 底层连接
+	reader      *bufio.Reader     // 读取缓冲
+	writeMu     sync.Mutex        // 写入的并发控制
+	compress    bool              // 是否协商了permessage-deflate压缩
+	readLimit   int64             // 单个消息的最大长度
+	closeSent   bool              // 是否已经发送关闭消息
+	pongHandler func(data []byte) // 收到pong消息时的回调
+}
+
+// 将当前请求升级为WebSocket连接，升级失败时返回错误并输出400状态码，
+// 升级成功之后返回内容不再经过Response输出
+func (r *Request) WebSocket() (*WebSocket, error) {
+	if err := r.checkWebSocket(); err != nil {
+		r.Response.WriteStatus(http.StatusBadRequest, err.Error())
+		return nil, err
+	}
+	compress := false
+	if r.Server.config.WebSocketCompression {
+		compress = wsOfferDeflate(r.Header.Values("Sec-WebSocket-Extensions"))
+	}
+	conn, rw, err := r.Response.Writer.Hijack()
+	if err != nil {
+		r.Response.WriteStatus(http.StatusInternalServerError)
+		return nil, err
+	}
+	var b strings.Builder
+	b.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	b.WriteString("Sec-WebSocket-Accept: " + wsAcceptKey(r.Header.Get("Sec-WebSocket-Key")) + "\r\n")
+	if compress {
+		b.WriteString("Sec-WebSocket-Extensions: permessage-deflate; server_no_context_takeover; client_no_context_takeover\r\n")
+	}
+	b.WriteString("\r\n")
+	conn.SetDeadline(time.Time{})
+	if _, err := conn.Write([]byte(b.String())); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	r.Response.Status = http.StatusSwitchingProtocols
+	return &WebSocket{
+		conn:      conn,
+		reader:    rw.Reader,
+		compress:  compress,
+		readLimit: r.Server.config.WebSocketReadLimit,
+	}, nil
+}
+
+// 检查WebSocket握手请求
+func (r *Request) checkWebSocket() error {
+	if r.Method != http.MethodGet {
+		return errors.New("websocket: method must be GET")
+	}
+	if !headerContainsToken(r.Header, "Connection", "upgrade") || !headerContainsToken(r.Header, "Upgrade", "websocket") {
+		return errors.New("websocket: not a websocket handshake")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		return errors.New("websocket: unsupported version")
+	}
+	if key, err := base64.StdEncoding.DecodeString(r.Header.Get("Sec-WebSocket-Key")); err != nil || len(key) != 16 {
+		return errors.New("websocket: invalid Sec-WebSocket-Key")
+	}
+	checkOrigin := r.Server.config.WebSocketCheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = wsSameOrigin
+	}
+	if !checkOrigin(r) {
+		return errors.New("websocket: origin not allowed")
+	}
+	return nil
+}
+
+// 默认的来源检查，没有Origin请求头或者Origin与请求域名一致时允许连接
+func wsSameOrigin(r *Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// 判断请求头中是否包含指定的值(不区分大小写，多个值使用","分隔)
+func headerContainsToken(header http.Header, name, token string) bool {
+	for _, v := range header.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// 判断客户端是否支持permessage-deflate压缩
+func wsOfferDeflate(extensions []string) bool {
+	for _, v := range extensions {
+		for _, ext := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(strings.Split(ext, ";")[0]), "permessage-deflate") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// 计算Sec-WebSocket-Accept
+func wsAcceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + wsAcceptGuid))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// 设置读取的超时时间，零值表示不超时
+func (ws *WebSocket) SetReadDeadline(t time.Time) error {
+	return ws.conn.SetReadDeadline(t)
+}
+
+// 设置写入的超时时间，零值表示不超时
+func (ws *WebSocket) SetWriteDeadline(t time.Time) error {
+	return ws.conn.SetWriteDeadline(t)
+}
+
+// 设置单个消息的最大长度(解压后)，超出时使用1009状态码关闭连接，小于等于0时不限制
+func (ws *WebSocket) SetReadLimit(limit int64) {
+	ws.readLimit = limit
+}
+
+// 设置收到pong消息时的回调
+func (ws *WebSocket) SetPongHandler(handler func(data []byte)) {
+	ws.pongHandler = handler
+}
+
+// 获取底层连接
+func (ws *WebSocket) RawConn() net.Conn {
+	return ws.conn
+}
+
+// 读取一个完整的文本或者二进制消息，分片的消息会被合并，
+// ping消息自动回复pong，收到关闭消息时回复关闭消息并返回*WebSocketCloseError
+func (ws *WebSocket) ReadMessage() (msgType int, data []byte, err error) {
+	var (
+		buffer     bytes.Buffer
+		compressed bool
+	)
+	for {
+		fin, rsv1, opcode, payload, err := ws.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+		switch opcode {
+		case WS_MSG_PING:
+			if err := ws.writeFrame(WS_MSG_PONG, false, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case WS_MSG_PONG:
+			if ws.pongHandler != nil {
+				ws.pongHandler(payload)
+			}
+			continue
+		case WS_MSG_CLOSE:
+			return 0, nil, ws.handleClose(payload)
+		case 0:
+			if msgType == 0 || rsv1 {
+				return 0, nil, ws.fail(WS_CLOSE_PROTOCOL_ERROR, "unexpected continuation frame")
+			}
+		case WS_MSG_TEXT, WS_MSG_BINARY:
+			if msgType != 0 {
+				return 0, nil, ws.fail(WS_CLOSE_PROTOCOL_ERROR, "expected continuation frame")
+			}
+			if rsv1 && !ws.compress {
+				return 0, nil, ws.fail(WS_CLOSE_PROTOCOL_ERROR, "unexpected compressed frame")
+			}
+			msgType, compressed = opcode, rsv1
+		default:
+			return 0, nil, ws.fail(WS_CLOSE_PROTOCOL_ERROR, "unknown opcode")
+		}
+		if ws.readLimit > 0 && int64(buffer.Len()+len(payload)) > ws.readLimit {
+			return 0, nil, ws.fail(WS_CLOSE_TOO_BIG, "message too big")
+		}
+		buffer.Write(payload)
+		if !fin {
+			continue
+		}
+		data = buffer.Bytes()
+		if compressed {
+			if data, err = ws.inflate(data); err != nil {
+				return 0, nil, err
+			}
+		}
+		if msgType == WS_MSG_TEXT && !utf8.Valid(data) {
+			return 0, nil, ws.fail(WS_CLOSE_INVALID_PAYLOAD, "invalid utf-8 text")
+		}
+		return msgType, data, nil
+	}
+}
+
+// 写入一个文本或者二进制消息，协商了压缩时消息会被压缩
+func (ws *WebSocket) WriteMessage(msgType int, data []byte) error {
+	if msgType != WS_MSG_TEXT && msgType != WS_MSG_BINARY {
+		return fmt.Errorf("websocket: invalid message type %d", msgType)
+	}
+	if !ws.compress {
+		return ws.writeFrame(msgType, false, data)
+	}
+	var buffer bytes.Buffer
+	w, _ := flate.NewWriter(&buffer, flate.DefaultCompression)
+	w.Write(data)
+	w.Flush()
+	return ws.writeFrame(msgType, true, bytes.TrimSuffix(buffer.Bytes(), []byte(wsDeflateTail)))
+}
+
+// 写入文本消息
+func (ws *WebSocket) WriteText(text string) error {
+	return ws.WriteMessage(WS_MSG_TEXT, []byte(text))
+}
+
+// 发送ping消息，<data>不能超过125字节
+func (ws *WebSocket) Ping(data []byte) error {
+	if len(data) > 125 {
+		return errors.New("websocket: control frame too long")
+	}
+	return ws.writeFrame(WS_MSG_PING, false, data)
+}
+
+// 使用指定的状态码以及原因发送关闭消息并关闭连接
+func (ws *WebSocket) CloseWithCode(code int, text string) error {
+	err := ws.writeClose(code, text)
+	if e := ws.conn.Close(); err == nil {
+		err = e
+	}
+	return err
+}
+
+// 使用正常关闭状态码关闭连接
+func (ws *WebSocket) Close() error {
+	return ws.CloseWithCode(WS_CLOSE_NORMAL, "")
+}
+
+// 处理收到的关闭消息，回复相同的状态码并关闭连接
+func (ws *WebSocket) handleClose(payload []byte) error {
+	closeErr := &WebSocketCloseError{Code: WS_CLOSE_NO_STATUS}
+	switch {
+	case len(payload) == 1:
+		return ws.fail(WS_CLOSE_PROTOCOL_ERROR, "invalid close frame")
+	case len(payload) >= 2:
+		closeErr.Code = int(binary.BigEndian.Uint16(payload))
+		closeErr.Text = string(payload[2:])
+		if !wsValidCloseCode(closeErr.Code) {
+			return ws.fail(WS_CLOSE_PROTOCOL_ERROR, "invalid close code")
+		}
+		if !utf8.Valid(payload[2:]) {
+			return ws.fail(WS_CLOSE_INVALID_PAYLOAD, "invalid utf-8 close reason")
+		}
+	}
+	code := closeErr.Code
+	if code == WS_CLOSE_NO_STATUS {
+		code = WS_CLOSE_NORMAL
+	}
+	ws.writeClose(code, "")
+	ws.conn.Close()
+	return closeErr
+}
+
+// 因为协议错误关闭连接，返回对应的错误
+func (ws *WebSocket) fail(code int, text string) error {
+	ws.writeClose(code, text)
+	ws.conn.Close()
+	return &WebSocketCloseError{Code: code, Text: text}
+}
+
+// 判断关闭状态码是否可以出现在关闭消息中
+func wsValidCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1011, code >= 3000 && code <= 4999:
+		return true
+	}
+	return false
+}
+
+// 发送关闭消息，只会发送一次
+func (ws *WebSocket) writeClose(code int, text string) error {
+	payload := make([]byte, 2, 2+len(text))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, text...)
+	if len(payload) > 125 {
+		payload = payload[:125]
+	}
+	return ws.writeFrame(WS_MSG_CLOSE, false, payload)
+}
+
+// 解压消息内容，解压后的长度同样受到最大长度的限制
+func (ws *WebSocket) inflate(data []byte) ([]byte, error) {
+	reader := flate.NewReader(io.MultiReader(bytes.NewReader(data), strings.NewReader(wsDeflateTail+"\x01\x00\x00\xff\xff")))
+	defer reader.Close()
+	var limited io.Reader = reader
+	if ws.readLimit > 0 {
+		limited = io.LimitReader(reader, ws.readLimit+1)
+	}
+	result, err := ioutil.ReadAll(limited)
+	if err != nil {
+		return nil, ws.fail(WS_CLOSE_INVALID_PAYLOAD, "invalid compressed data")
+	}
+	if ws.readLimit > 0 && int64(len(result)) > ws.readLimit {
+		return nil, ws.fail(WS_CLOSE_TOO_BIG, "message too big")
+	}
+	return result, nil
+}
+
+// 读取一个数据帧，客户端发送的数据帧必须经过掩码处理
+func (ws *WebSocket) readFrame() (fin, rsv1 bool, opcode int, payload []byte, err error) {
+	var header [2]byte
+	if _, err = io.ReadFull(ws.reader, header[:]); err != nil {
+		return
+	}
+	fin, rsv1, opcode = header[0]&0x80 != 0, header[0]&0x40 != 0, int(header[0]&0x0f)
+	masked, length := header[1]&0x80 != 0, uint64(header[1]&0x7f)
+	if header[0]&0x30 != 0 {
+		err = ws.fail(WS_CLOSE_PROTOCOL_ERROR, "reserved bits must be zero")
+		return
+	}
+	if !masked {
+		err = ws.fail(WS_CLOSE_PROTOCOL_ERROR, "client frame must be masked")
+		return
+	}
+	if opcode >= WS_MSG_CLOSE && (!fin || length > 125 || rsv1) {
+		err = ws.fail(WS_CLOSE_PROTOCOL_ERROR, "invalid control frame")
+		return
+	}
+	switch length {
+	case 126:
+		var b [2]byte
+		if _, err = io.ReadFull(ws.reader, b[:]); err != nil {
+			return
+		}
+		length = uint64(binary.BigEndian.Uint16(b[:]))
+	case 127:
+		var b [8]byte
+		if _, err = io.ReadFull(ws.reader, b[:]); err != nil {
+			return
+		}
+		length = binary.BigEndian.Uint64(b[:])
+	}
+	if length > wsMaxFrameSize || (ws.readLimit > 0 && length > uint64(ws.readLimit)) {
+		err = ws.fail(WS_CLOSE_TOO_BIG, "message too big")
+		return
+	}
+	var mask [4]byte
+	if _, err = io.ReadFull(ws.reader, mask[:]); err != nil {
+		return
+	}
+	// 按照实际收到的数据分配内存，避免根据客户端声明的长度直接分配
+	size := wsFrameBufferSize
+	if length < uint64(size) {
+		size = int(length)
+	}
+	buffer := bytes.NewBuffer(make([]byte, 0, size))
+	if _, err = io.CopyN(buffer, ws.reader, int64(length)); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return
+	}
+	payload = buffer.Bytes()
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return
+}
+
+// 写入一个不分片的数据帧，服务端发送的数据帧不经过掩码处理，关闭消息发送之后不能再写入
+func (ws *WebSocket) writeFrame(opcode int, rsv1 bool, payload []byte) error {
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+	if ws.closeSent {
+		return ErrWebSocketClosed
+	}
+	header := make([]byte, 2, 10)
+	header[0] = 0x80 | byte(opcode)
+	if rsv1 {
+		header[0] |= 0x40
+	}
+	switch length := len(payload); {
+	case length <= 125:
+		header[1] = byte(length)
+	case length <= 0xffff:
+		header[1] = 126
+		header = append(header, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(length))
+	default:
+		header[1] = 127
+		header = append(header, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(header[2:], uint64(length))
+	}
+	if opcode == WS_MSG_CLOSE {
+		ws.closeSent = true
+	}
+	if _, err := ws.conn.Write(append(header, payload...)); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+package qhttp
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"grt/q/utils/conv"
+)
+
+// JSONP回调方法名称的格式，避免输出任意的脚本内容
+var jsonpCallbackRegex = regexp.MustCompile(`^[\w.$\[\]]+$`)
+
+// 请求对应的返回数据操作对象，返回内容先写入缓冲区，在请求处理结束后统一输出
+type Response struct {
+	*ResponseWriter                 // 带缓冲的返回数据写入对象
+	Writer          *ResponseWriter // 带缓冲的返回数据写入对象(实现了http.ResponseWriter接口)
+	Server          *Server         // 关联的服务器对象
+	request         *Request        // 关联的请求对象
+	compress        *CompressConfig // 中间件设置的压缩配置，为nil时使用服务级别的压缩配置
+	sse             *SSE            // 切换为事件流之后的事件输出对象
+}
+
+// 创建一个返回数据操作对象
+func newResponse(s *Server, w http.ResponseWriter) *Response {
+	writer := newResponseWriter(w)
+	return &Response{
+		ResponseWriter: writer,
+		Writer:         writer,
+		Server:         s,
+	}
+}
+
+// 写入返回内容，可以同时写入多个任意类型的值
+func (r *Response) Write(content ...interface{}) {
+	for _, v := range content {
+		switch value := v.(type) {
+		case []byte:
+			r.Writer.Write(value)
+		case string:
+			r.Writer.Write([]byte(value))
+		default:
+			r.Writer.Write([]byte(conv.String(v)))
+		}
+	}
+}
+
+// 写入返回内容并换行
+func (r *Response) Writeln(content ...interface{}) {
+	r.Write(append(content, "\n")...)
+}
+
+// 格式化写入返回内容
+func (r *Response) Writef(format string, params ...interface{}) {
+	r.Write(fmt.Sprintf(format, params...))
+}
+
+// 格式化写入返回内容并换行
+func (r *Response) Writefln(format string, params ...interface{}) {
+	r.Write(fmt.Sprintf(format, params...), "\n")
+}
+
+// 写入JSON格式的返回内容
+func (r *Response) WriteJson(content interface{}) error {
+	b, err := json.Marshal(content)
+	if err != nil {
+		return err
+	}
+	r.Header().Set("Content-Type", "application/json")
+	r.Write(b)
+	return nil
+}
+
+// 写入JSONP格式的返回内容，回调方法名称通过callback参数指定，未指定或者格式不正确时输出JSON格式
+func (r *Response) WriteJsonP(content interface{}) error {
+	callback := r.request.GetQueryString("callback")
+	if !jsonpCallbackRegex.MatchString(callback) {
+		return r.WriteJson(content)
+	}
+	b, err := json.Marshal(content)
+	if err != nil {
+		return err
+	}
+	r.Header().Set("Content-Type", "application/javascript")
+	r.Write(callback, "(", b, ")")
+	return nil
+}
+
+// 写入XML格式的返回内容，map类型的内容使用<rootTag>作为根节点(默认为xml)
+func (r *Response) WriteXml(content interface{}, rootTag ...string) error {
+	var (
+		b   []byte
+		err error
+	)
+	if m, ok := content.(map[string]interface{}); ok {
+		root := "xml"
+		if len(rootTag) > 0 && rootTag[0] != "" {
+			root = rootTag[0]
+		}
+		b = encodeXmlMap(root, m)
+	} else if b, err = xml.Marshal(content); err != nil {
+		return err
+	}
+	r.Header().Set("Content-Type", "application/xml")
+	r.Write(b)
+	return nil
+}
+
+// 设置返回的状态码，未指定内容并且缓冲区为空时输出状态码对应的描述
+func (r *Response) WriteStatus(status int, content ...interface{}) {
+	r.WriteHeader(status)
+	if len(content) > 0 {
+		r.Write(content...)
+	} else if r.buffer.Len() == 0 {
+		r.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		r.Write(http.StatusText(status))
+	}
+}
+
+// 重定向到指定的地址，默认使用302状态码
+func (r *Response) RedirectTo(location string, code ...int) {
+	status := http.StatusFound
+	if len(code) > 0 {
+		status = code[0]
+	}
+	r.Header().Set("Location", location)
+	r.WriteHeader(status)
+}
+
+// 重定向到来源页面，没有来源页面时重定向到首页
+func (r *Response) RedirectBack(code ...int) {
+	location := r.request.Referer()
+	if location == "" {
+		location = "/"
+	}
+	r.RedirectTo(location, code...)
+}
+
+// 输出文件内容，支持ETag、Last-Modified以及Range请求
+func (r *Response) ServeFile(path string) {
+	r.serveFile(path, "", false)
+}
+
+// 以下载的方式输出文件，<name>为下载的文件名称，默认为原文件名称
+func (r *Response) ServeFileDownload(path string, name ...string) {
+	downloadName := filepath.Base(path)
+	if len(name) > 0 && name[0] != "" {
+		downloadName = name[0]
+	}
+	r.serveFile(path, downloadName, true)
+}
+
+// 输出文件内容，文件内容不经过缓冲区直接输出到客户端
+func (r *Response) serveFile(path, name string, download bool) {
+	file, err := os.Open(path)
+	if err != nil {
+		r.WriteStatus(http.StatusNotFound)
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		r.WriteStatus(http.StatusForbidden)
+		return
+	}
+	if download {
+		r.Header().Set("Content-Type", "application/octet-stream")
+		r.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+			"filename": name,
+		}))
+	}
+	if r.Header().Get("ETag") == "" {
+		r.Header().Set("ETag", fmt.Sprintf(`W/"%x-%x"`, info.ModTime().UnixNano(), info.Size()))
+	}
+	r.Writer.setDirect()
+	http.ServeContent(r.Writer, r.request.Request, info.Name(), info.ModTime(), file)
+}
+
+// 获取缓冲区中的返回内容
+func (r *Response) Buffer() []byte {
+	return r.buffer.Bytes()
+}
+
+// 获取缓冲区中的返回内容字符串
+func (r *Response) BufferString() string {
+	return r.buffer.String()
+}
+
+// 获取缓冲区中返回内容的长度
+func (r *Response) BufferLength() int {
+	return r.buffer.Len()
+}
+
+// 使用<data>替换缓冲区中的返回内容
+func (r *Response) SetBuffer(data []byte) {
+	r.buffer.Reset()
+	r.buffer.Write(data)
+}
+
+// 清空缓冲区中的返回内容
+func (r *Response) ClearBuffer() {
+	r.buffer.Reset()
+}
+
+// 输出缓冲区中的返回内容，请求处理结束后由服务自动调用，事件流在输出之前关闭
+func (r *Response) Output() {
+	if r.sse != nil {
+		r.sse.Close()
+	}
+	r.compressBuffer()
+	r.Writer.Flush()
+}
+
+// 将map编码为XML，子节点按照名称排序，slice类型的值输出为多个同名节点
+func encodeXmlMap(root string, m map[string]interface{}) []byte {
+	var b strings.Builder
+	writeXmlNode(&b, root, m)
+	return []byte(b.String())
+}
+
+// 输出单个XML节点
+func writeXmlNode(b *strings.Builder, name string, value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b.WriteString("<" + name + ">")
+		for _, k := range keys {
+			writeXmlNode(b, k, v[k])
+		}
+		b.WriteString("</" + name + ">")
+
+	case []interface{}:
+		for _, item := range v {
+			writeXmlNode(b, name, item)
+		}
+
+	default:
+		b.WriteString("<" + name + ">")
+		xml.EscapeText(b, []byte(conv.String(value)))
+		b.WriteString("</" + name + ">")
+	}
+}
@@ -0,0 +1,176 @@
+package qhttp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// 默认压缩的最小返回内容长度(字节)
+const DEFAULT_COMPRESS_MIN_LENGTH = 1024
+
+// 默认压缩的返回内容类型，以"/*"结尾表示匹配该类型下的所有子类型
+var defaultCompressTypes = []string{
+	"text/*",
+	"application/json",
+	"application/javascript",
+	"application/xml",
+	"application/x-javascript",
+	"image/svg+xml",
+}
+
+// 返回内容的压缩配置，只压缩缓冲模式下的返回内容，文件、SSE以及WebSocket等直接输出的内容不会被压缩
+type CompressConfig struct {
+	Disabled     bool     // 关闭压缩，用于在分组中关闭服务级别的压缩配置
+	Level        int      // 压缩级别(1-9)，为0时使用默认级别
+	MinLength    int      // 压缩的最小返回内容长度(字节)，为0时使用DEFAULT_COMPRESS_MIN_LENGTH
+	ContentTypes []string // 压缩的返回内容类型，为空时使用默认类型，例如: text/*、application/json
+}
+
+// 开启服务级别的返回内容压缩，不传递配置时使用默认配置
+func (s *Server) SetCompress(config ...CompressConfig) {
+	c := CompressConfig{}
+	if len(config) > 0 {
+		c = config[0]
+	}
+	s.config.Compress = &c
+}
+
+// 设置分组中注册路由的压缩配置，覆盖服务级别的压缩配置，只对之后在分组中注册的路由生效
+func (g *RouterGroup) Compress(config ...CompressConfig) *RouterGroup {
+	return g.Middleware(MiddlewareCompress(config...))
+}
+
+// 设置请求的压缩配置的中间件，覆盖服务级别的压缩配置
+func MiddlewareCompress(config ...CompressConfig) HandlerFunc {
+	c := CompressConfig{}
+	if len(config) > 0 {
+		c = config[0]
+	}
+	return func(r *Request) {
+		r.Response.compress = &c
+		r.Middleware.Next()
+	}
+}
+
+// 根据客户端的Accept-Encoding压缩缓冲区中的返回内容，在输出之前调用
+func (r *Response) compressBuffer() {
+	c := r.compress
+	if c == nil {
+		c = r.Server.config.Compress
+	}
+	w := r.Writer
+	if c == nil || c.Disabled || w.direct || w.hijacked || w.wroteHeader {
+		return
+	}
+	header := w.Header()
+	minLength := c.MinLength
+	if minLength <= 0 {
+		minLength = DEFAULT_COMPRESS_MIN_LENGTH
+	}
+	if w.buffer.Len() < minLength || header.Get("Content-Encoding") != "" ||
+		w.Status < http.StatusOK || w.Status == http.StatusNoContent || w.Status == http.StatusNotModified {
+		return
+	}
+	// 压缩之后无法再根据内容检测类型，需要提前设置
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", http.DetectContentType(w.buffer.Bytes()))
+	}
+	if !compressibleType(header.Get("Content-Type"), c.ContentTypes) {
+		return
+	}
+	// 返回内容是否压缩取决于请求的Accept-Encoding，缓存需要区分
+	addVary(header, "Accept-Encoding")
+	encoding := negotiateEncoding(r.request.Header.Get("Accept-Encoding"))
+	if encoding == "" {
+		return
+	}
+	level := c.Level
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+	var (
+		buffer  = &bytes.Buffer{}
+		encoder io.WriteCloser
+		err     error
+	)
+	if encoding == "gzip" {
+		encoder, err = gzip.NewWriterLevel(buffer, level)
+	} else {
+		encoder, err = zlib.NewWriterLevel(buffer, level)
+	}
+	if err != nil {
+		return
+	}
+	encoder.Write(w.buffer.Bytes())
+	if encoder.Close() != nil || buffer.Len() >= w.buffer.Len() {
+		return
+	}
+	header.Set("Content-Encoding", encoding)
+	header.Del("Content-Length")
+	header.Del("Accept-Ranges")
+	// 压缩后的内容与原内容字节不同，强校验的ETag需要转换为弱校验
+	if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		header.Set("ETag", "W/"+etag)
+	}
+	r.SetBuffer(buffer.Bytes())
+}
+
+// 判断返回内容类型是否需要压缩
+func compressibleType(contentType string, types []string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	if mediaType == "text/event-stream" {
+		return false
+	}
+	if len(types) == 0 {
+		types = defaultCompressTypes
+	}
+	for _, t := range types {
+		t = strings.ToLower(t)
+		if t == mediaType || (strings.HasSuffix(t, "/*") && strings.HasPrefix(mediaType, t[:len(t)-1])) {
+			return true
+		}
+	}
+	return false
+}
+
+// 根据Accept-Encoding选择压缩方式，支持gzip以及deflate，权重相同时优先使用gzip，不支持时返回空字符串
+func negotiateEncoding(acceptEncoding string) string {
+	weights := make(map[string]float64)
+	for _, item := range strings.Split(acceptEncoding, ",") {
+		params := strings.Split(item, ";")
+		name := strings.ToLower(strings.TrimSpace(params[0]))
+		if name == "" {
+			continue
+		}
+		q := 1.0
+		for _, p := range params[1:] {
+			p = strings.TrimSpace(p)
+			if strings.HasPrefix(p, "q=") {
+				if v, err := strconv.ParseFloat(p[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		weights[name] = q
+	}
+	encoding, weight := "", 0.0
+	for _, name := range []string{"gzip", "deflate"} {
+		q, ok := weights[name]
+		if !ok {
+			q, ok = weights["*"]
+		}
+		if ok && q > weight {
+			encoding, weight = name, q
+		}
+	}
+	return encoding
+}
@@ -0,0 +1,167 @@
+package qhttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server-Sent Events事件输出对象，事件内容不经过缓冲区，写入之后立即输出到客户端，方法都是并发安全的
+type SSE struct {
+	mu        sync.Mutex    // 写入的并发控制
+	response  *Response     // 关联的返回对象
+	closed    bool          // 是否已经关闭
+	heartbeat chan struct{} // 心跳协程的停止通知
+	stopped   chan struct{} // 心跳协程已经结束的通知
+}
+
+// 事件流已经关闭
+var ErrSSEClosed = errors.New("sse: stream closed")
+
+// 将返回切换为Server-Sent Events事件流，返回头会立即输出，重复调用时返回同一个事件流对象，
+// 处理方法结束时事件流会被自动关闭
+func (r *Response) SSE() *SSE {
+	if r.sse != nil {
+		return r.sse
+	}
+	header := r.Header()
+	header.Set("Content-Type", "text/event-stream; charset=utf-8")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	// 禁止nginx等反向代理缓冲事件流
+	header.Set("X-Accel-Buffering", "no")
+	header.Del("Content-Length")
+	// 事件流是长连接，不受服务写入超时时间的限制
+	http.NewResponseController(r.Writer.RawWriter()).SetWriteDeadline(time.Time{})
+	r.Writer.setDirect()
+	r.Writer.WriteHeader(http.StatusOK)
+	r.Writer.Flush()
+	r.sse = &SSE{response: r}
+	return r.sse
+}
+
+// 获取客户端重连时携带的最后一个事件ID，用于从断开的位置继续推送
+func (s *SSE) LastEventId() string {
+	r := s.response.request
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		return id
+	}
+	return r.URL.Query().Get("lastEventId")
+}
+
+// 获取请求结束(客户端断开连接)的通知
+func (s *SSE) Done() <-chan struct{} {
+	if r := s.response.request; r != nil {
+		return r.Context().Done()
+	}
+	return context.Background().Done()
+}
+
+// 发送事件，<event>以及<id>为空时不输出对应的字段，<data>中的多行内容会输出为多个data字段
+func (s *SSE) Send(event, id, data string) error {
+	var b strings.Builder
+	if id = sseField(id); id != "" {
+		b.WriteString("id: " + id + "\n")
+	}
+	if event = sseField(event); event != "" {
+		b.WriteString("event: " + event + "\n")
+	}
+	for _, line := range strings.Split(strings.Replace(data, "\r\n", "\n", -1), "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+	return s.write(b.String())
+}
+
+// 发送只包含数据的事件
+func (s *SSE) SendData(data string) error {
+	return s.Send("", "", data)
+}
+
+// 设置客户端断开之后的重连间隔
+func (s *SSE) Retry(interval time.Duration) error {
+	return s.write(fmt.Sprintf("retry: %d\n\n", interval/time.Millisecond))
+}
+
+// 发送注释，客户端会忽略注释内容，可以用于保持连接
+func (s *SSE) Comment(text string) error {
+	var b strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		b.WriteString(": " + strings.TrimRight(line, "\r") + "\n")
+	}
+	b.WriteString("\n")
+	return s.write(b.String())
+}
+
+// 按照<interval>间隔发送心跳注释，避免连接被代理或者客户端因为空闲而断开，
+// 事件流关闭、请求结束或者写入失败时停止
+func (s *SSE) Heartbeat(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed || s.heartbeat != nil {
+		return
+	}
+	s.heartbeat = make(chan struct{})
+	s.stopped = make(chan struct{})
+	go func(stop, stopped chan struct{}) {
+		ticker := time.NewTicker(interval)
+		defer func() {
+			ticker.Stop()
+			close(stopped)
+		}()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-s.Done():
+				return
+			case <-ticker.C:
+				if s.Comment("heartbeat") != nil {
+					return
+				}
+			}
+		}
+	}(s.heartbeat, s.stopped)
+}
+
+// 关闭事件流，停止心跳并等待心跳协程结束，之后的写入都会返回ErrSSEClosed
+func (s *SSE) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	stopped := s.stopped
+	if s.heartbeat != nil {
+		close(s.heartbeat)
+	}
+	s.mu.Unlock()
+	// 心跳协程写入时需要持有锁，等待时不能持有锁
+	if stopped != nil {
+		<-stopped
+	}
+}
+
+// 写入内容并立即输出到客户端
+func (s *SSE) write(content string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrSSEClosed
+	}
+	if _, err := s.response.Writer.Write([]byte(content)); err != nil {
+		return err
+	}
+	s.response.Writer.Flush()
+	return nil
+}
+
+// 去掉字段中的换行符，避免破坏事件格式
+func sseField(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "", "\x00", "").Replace(value)
+}
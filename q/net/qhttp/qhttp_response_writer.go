@@ -0,0 +1,130 @@
+package qhttp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+// 带缓冲的返回数据写入对象，返回内容在请求处理结束后统一输出，
+// 在输出之前可以对缓冲区中的内容进行读取以及修改。
+type ResponseWriter struct {
+	writer       http.ResponseWriter // 原始的返回数据写入对象
+	Status       int                 // 返回的状态码
+	buffer       *bytes.Buffer       // 返回内容的缓冲区
+	bytesWritten int64               // 实际输出到客户端的字节数
+	wroteHeader  bool                // 返回头是否已经输出
+	direct       bool                // 是否为直接输出模式(不经过缓冲区)
+	hijacked     bool                // 连接是否已经被接管
+	headerHooks  []func()            // 返回头输出之前执行的回调
+}
+
+// 创建一个带缓冲的写入对象
+func newResponseWriter(w http.ResponseWriter) *ResponseWriter {
+	return &ResponseWriter{
+		writer: w,
+		Status: http.StatusOK,
+		buffer: bytes.NewBuffer(nil),
+	}
+}
+
+// 获取返回头
+func (w *ResponseWriter) Header() http.Header {
+	return w.writer.Header()
+}
+
+// 设置返回的状态码，缓冲模式下状态码在输出时才会写入
+func (w *ResponseWriter) WriteHeader(status int) {
+	w.Status = status
+	if w.direct {
+		w.writeHeader()
+	}
+}
+
+// 写入返回内容，缓冲模式下写入到缓冲区，直接输出模式下直接写入客户端
+func (w *ResponseWriter) Write(data []byte) (int, error) {
+	if w.hijacked {
+		return 0, http.ErrHijacked
+	}
+	if !w.direct {
+		return w.buffer.Write(data)
+	}
+	w.writeHeader()
+	n, err := w.writer.Write(data)
+	w.bytesWritten += int64(n)
+	return n, err
+}
+
+// 将缓冲区中的内容输出到客户端，并刷新底层连接
+func (w *ResponseWriter) Flush() {
+	if w.hijacked {
+		return
+	}
+	w.writeHeader()
+	if w.buffer.Len() > 0 {
+		n, _ := w.writer.Write(w.buffer.Bytes())
+		w.bytesWritten += int64(n)
+		w.buffer.Reset()
+	}
+	if flusher, ok := w.writer.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// 接管底层的连接，接管之后所有的输出都由调用方负责
+func (w *ResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.writer.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the response writer does not support hijacking")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+	return conn, rw, err
+}
+
+// 获取原始的返回数据写入对象
+func (w *ResponseWriter) RawWriter() http.ResponseWriter {
+	return w.writer
+}
+
+// 获取实际输出到客户端的字节数
+func (w *ResponseWriter) BytesWritten() int64 {
+	return w.bytesWritten
+}
+
+// 切换为直接输出模式，缓冲区中已有的内容将会先输出，用于文件以及流式数据的输出
+func (w *ResponseWriter) setDirect() {
+	if w.direct {
+		return
+	}
+	w.direct = true
+	if w.buffer.Len() > 0 {
+		w.Flush()
+	}
+}
+
+// 添加返回头输出之前执行的回调，用于输出Cookie等需要在返回头中设置的数据
+func (w *ResponseWriter) onWriteHeader(f func()) {
+	w.headerHooks = append(w.headerHooks, f)
+}
+
+// 输出返回头，只会输出一次
+func (w *ResponseWriter) writeHeader() {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	for _, f := range w.headerHooks {
+		f()
+	}
+	// 缓冲模式下返回内容的长度是确定的
+	if !w.direct && w.buffer.Len() > 0 && w.Header().Get("Content-Length") == "" {
+		w.Header().Set("Content-Length", strconv.Itoa(w.buffer.Len()))
+	}
+	w.writer.WriteHeader(w.Status)
+}
@@ -0,0 +1,441 @@
+package qhttp
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HTTP服务对象，同一进程中可以按照名称创建多个服务
+type Server struct {
+	name       string                 // 服务名称
+	config     ServerConfig           // 服务配置
+	mu         sync.RWMutex           // 服务状态的并发控制
+	status     int                    // 服务状态
+	servers    []*http.Server         // 底层的http服务(每个监听地址一个)
+	listeners  []*serverListener      // 监听对象
+	closeChan  chan struct{}          // 服务关闭通知
+	routeTrees map[string]*routerNode // 域名与路由树的映射
+	itemOrder  int                    // 处理项的注册顺序
+	middleware []HandlerFunc          // 全局中间件
+	trusted    []*net.IPNet           // 解析过后的可信代理网段
+	sessions   SessionStorage         // 未配置会话存储时使用的内存存储
+	logFiles   sync.Map               // 日志文件路径与日志文件(*logFile)的映射，与服务状态使用不同的并发控制
+
+	errorHandler   ErrorHandlerFunc       // 错误处理方法
+	statusHandlers map[string]HandlerFunc // 状态码与处理方法的映射，键名格式为: 状态码@域名
+
+	inflightMu sync.Mutex       // 正在处理的请求的并发控制
+	inflight   map[int]*Request // 正在处理的请求，键名为请求ID
+}
+
+// 服务的监听对象
+type serverListener struct {
+	net.Listener
+	addr  string // 配置的监听地址
+	https bool   // 是否为HTTPS监听
+}
+
+var (
+	// 服务名称与服务对象的映射
+	serverMapping = make(map[string]*Server)
+	// 服务映射的并发控制
+	serverMu sync.Mutex
+	// 用于等待所有服务结束
+	serverWaitGroup sync.WaitGroup
+)
+
+// 获取或者创建一个指定名称的服务对象，名称为空时返回默认服务
+func GetServer(name ...string) *Server {
+	serverName := DEFAULT_SERVER
+	if len(name) > 0 && name[0] != "" {
+		serverName = name[0]
+	}
+	serverMu.Lock()
+	defer serverMu.Unlock()
+	if s, ok := serverMapping[serverName]; ok {
+		return s
+	}
+	s := &Server{
+		name:       serverName,
+		config:     defaultServerConfig,
+		routeTrees: make(map[string]*routerNode),
+	}
+	serverMapping[serverName] = s
+	return s
+}
+
+// 获取所有运行中的服务
+func runningServers() []*Server {
+	serverMu.Lock()
+	defer serverMu.Unlock()
+	servers := make([]*Server, 0, len(serverMapping))
+	for _, s := range serverMapping {
+		if s.Status() == SERVER_STATUS_RUNNING {
+			servers = append(servers, s)
+		}
+	}
+	return servers
+}
+
+// 阻塞等待所有服务结束
+func Wait() {
+	serverWaitGroup.Wait()
+}
+
+// 获取服务名称
+func (s *Server) GetName() string {
+	return s.name
+}
+
+// 获取服务状态
+func (s *Server) Status() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.status
+}
+
+// 启动服务，在所有配置的地址上监听，非阻塞，
+// 当前进程由平滑重启创建时直接使用从父进程继承的监听对象
+func (s *Server) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.status == SERVER_STATUS_RUNNING {
+		return fmt.Errorf("server '%s' is already running", s.name)
+	}
+	listeners := inheritedListeners(s.name)
+	if len(listeners) == 0 {
+		var err error
+		if listeners, err = s.listen(); err != nil {
+			return err
+		}
+	}
+	var tlsConfig *tls.Config
+	for _, ln := range listeners {
+		if ln.https && tlsConfig == nil {
+			var err error
+			if tlsConfig, err = s.newTLSConfig(); err != nil {
+				for _, v := range listeners {
+					v.Close()
+				}
+				return err
+			}
+		}
+	}
+	s.listeners = listeners
+	s.servers = make([]*http.Server, len(listeners))
+	for i, ln := range listeners {
+		s.servers[i] = s.newHttpServer(ln.Addr().String())
+		if ln.https {
+			s.servers[i].TLSConfig = tlsConfig
+			go s.serve(s.servers[i], tls.NewListener(ln.Listener, tlsConfig))
+		} else {
+			go s.serve(s.servers[i], ln.Listener)
+		}
+	}
+	s.status = SERVER_STATUS_RUNNING
+	s.closeChan = make(chan struct{})
+	serverWaitGroup.Add(1)
+	if s.config.Graceful {
+		handleGracefulSignal()
+	}
+	return nil
+}
+
+// 在所有配置的HTTP以及HTTPS地址上监听
+func (s *Server) listen() ([]*serverListener, error) {
+	items := make([]*serverListener, 0)
+	for _, addr := range s.addrs() {
+		items = append(items, &serverListener{addr: addr})
+	}
+	if s.config.HTTPSCertFile != "" {
+		for _, addr := range s.httpsAddrs() {
+			items = append(items, &serverListener{addr: addr, https: true})
+		}
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("server '%s' has no address to listen", s.name)
+	}
+	for i, item := range items {
+		ln, err := net.Listen("tcp", item.addr)
+		if err != nil {
+			for _, v := range items[:i] {
+				v.Close()
+			}
+			return nil, err
+		}
+		item.Listener = ln
+	}
+	return items, nil
+}
+
+// 启动服务并阻塞，直到服务被关闭
+func (s *Server) Run() error {
+	if err := s.Start(); err != nil {
+		return err
+	}
+	s.mu.RLock()
+	closeChan := s.closeChan
+	s.mu.RUnlock()
+	<-closeChan
+	return nil
+}
+
+// 关闭服务，立即停止接收新的连接，并等待所有正在处理的请求结束，
+// <ctx>结束时仍有未处理完的请求则强制关闭所有连接并返回<ctx>的错误
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	if s.status != SERVER_STATUS_RUNNING {
+		s.mu.Unlock()
+		return nil
+	}
+	servers, closeChan := s.servers, s.closeChan
+	s.status = SERVER_STATUS_STOPPED
+	s.servers = nil
+	s.listeners = nil
+	s.mu.Unlock()
+
+	defer func() {
+		close(closeChan)
+		serverWaitGroup.Done()
+	}()
+	errs := make(chan error, len(servers))
+	for _, v := range servers {
+		go func(server *http.Server) {
+			errs <- server.Shutdown(ctx)
+		}(v)
+	}
+	var err error
+	for range servers {
+		if e := <-errs; e != nil && err == nil {
+			err = e
+		}
+	}
+	// 被接管的连接(例如WebSocket)不受http.Server管理，需要根据请求ID等待
+	if err == nil {
+		err = s.waitInflight(ctx)
+	}
+	if err != nil {
+		for _, v := range servers {
+			v.Close()
+		}
+	}
+	return err
+}
+
+// 获取正在处理的请求ID列表
+func (s *Server) InflightRequests() []int {
+	s.inflightMu.Lock()
+	defer s.inflightMu.Unlock()
+	ids := make([]int, 0, len(s.inflight))
+	for id := range s.inflight {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// 记录开始处理的请求
+func (s *Server) addInflight(r *Request) {
+	s.inflightMu.Lock()
+	defer s.inflightMu.Unlock()
+	if s.inflight == nil {
+		s.inflight = make(map[int]*Request)
+	}
+	s.inflight[r.Id] = r
+}
+
+// 删除处理完成的请求
+func (s *Server) removeInflight(r *Request) {
+	s.inflightMu.Lock()
+	defer s.inflightMu.Unlock()
+	delete(s.inflight, r.Id)
+}
+
+// 等待所有正在处理的请求结束
+func (s *Server) waitInflight(ctx context.Context) error {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		s.inflightMu.Lock()
+		n := len(s.inflight)
+		s.inflightMu.Unlock()
+		if n == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// 获取实际监听的地址列表(包含HTTPS地址)，服务未启动时返回空
+func (s *Server) ListenedAddrs() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	addrs := make([]string, len(s.listeners))
+	for i, v := range s.listeners {
+		addrs[i] = v.Addr().String()
+	}
+	return addrs
+}
+
+// 获取实际监听的HTTPS地址列表，服务未启动时返回空
+func (s *Server) ListenedHTTPSAddrs() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	addrs := make([]string, 0)
+	for _, v := range s.listeners {
+		if v.https {
+			addrs = append(addrs, v.Addr().String())
+		}
+	}
+	return addrs
+}
+
+// 创建底层的http服务
+func (s *Server) newHttpServer(addr string) *http.Server {
+	return &http.Server{
+		Addr:           addr,
+		Handler:        s,
+		ReadTimeout:    s.config.ReadTimeout,
+		WriteTimeout:   s.config.WriteTimeout,
+		IdleTimeout:    s.config.IdleTimeout,
+		MaxHeaderBytes: s.config.MaxHeaderBytes,
+	}
+}
+
+// 在指定的监听对象上执行服务
+func (s *Server) serve(server *http.Server, ln net.Listener) {
+	if err := server.Serve(ln); err != nil && err != http.ErrServerClosed {
+		log.Printf("[qhttp] server '%s' serve on %s failed: %v", s.name, server.Addr, err)
+	}
+}
+
+// 处理HTTP请求，每个请求都会创建一个Request对象
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	request := newRequest(s, r, w)
+	s.addInflight(request)
+	defer s.removeInflight(request)
+	defer request.removeUploadFiles()
+	defer func() {
+		// HOOK以及输出过程中的异常
+		if e := recover(); e != nil {
+			s.handlePanic(request, e, debug.Stack())
+			request.Response.Output()
+		}
+		s.niceCallCloseHook(request)
+		request.LeaveTime = time.Now().UnixNano() / 1000
+		s.handleAccessLog(request)
+	}()
+	s.niceServe(request)
+	s.callHookHandler(HOOK_BEFORE_OUTPUT, request)
+	request.Session.close()
+	request.Response.Output()
+	s.callHookHandler(HOOK_AFTER_OUTPUT, request)
+}
+
+// 执行请求结束之前的HOOK方法，异常与其他HOOK点一样交给错误处理，保证访问日志始终被记录
+func (s *Server) niceCallCloseHook(r *Request) {
+	defer func() {
+		if e := recover(); e != nil {
+			s.handlePanic(r, e, debug.Stack())
+			r.Response.Output()
+		}
+	}()
+	s.callHookHandler(HOOK_BEFORE_CLOSE, r)
+}
+
+// 执行服务并捕获异常，异常交给错误处理方法处理，没有错误处理方法时输出状态码对应的页面
+func (s *Server) niceServe(r *Request) {
+	defer func() {
+		if e := recover(); e != nil {
+			s.handlePanic(r, e, debug.Stack())
+		}
+	}()
+	s.serveRequest(r)
+	s.callStatusHandler(r)
+}
+
+// 检索并执行请求对应的静态文件或者路由
+func (s *Server) serveRequest(r *Request) {
+	if s.redirectHTTPS(r) {
+		return
+	}
+	staticFile := ""
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		staticFile = s.searchStaticFile(r.URL.Path)
+	}
+	var (
+		item    *handlerItem
+		allowed []string
+	)
+	if staticFile == "" || s.config.RouteOverStatic {
+		item, r.routerVars, allowed = s.searchHandler(r.Method, r.URL.Path, r.parsedHost)
+		if item == nil {
+			if preflight, vars := s.searchPreflightHandler(r, allowed); preflight != nil {
+				item, r.routerVars = preflight, vars
+			}
+		}
+	}
+	if item != nil {
+		r.Router = item.router
+	}
+	s.callHookHandler(HOOK_BEFORE_SERVE, r)
+	if !r.exit {
+		if item == nil && staticFile != "" {
+			s.serveStaticFile(r, staticFile)
+		} else if item == nil {
+			if len(allowed) > 0 {
+				r.Response.Header().Set("Allow", strings.Join(allowed, ", "))
+				r.Response.WriteStatus(http.StatusMethodNotAllowed)
+			} else {
+				r.Response.WriteStatus(http.StatusNotFound)
+			}
+		} else {
+			r.Middleware = &Middleware{
+				request:  r,
+				handlers: s.buildHandlers(r, item),
+			}
+			r.Middleware.Next()
+		}
+	}
+	s.callHookHandler(HOOK_AFTER_SERVE, r)
+}
+
+// 获取会话存储，未配置时使用服务自身的内存存储
+func (s *Server) sessionStorage() SessionStorage {
+	if s.config.SessionStorage != nil {
+		return s.config.SessionStorage
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sessions == nil {
+		s.sessions = NewSessionStorageMemory()
+	}
+	return s.sessions
+}
+
+// 执行处理方法，并捕获主动退出请求流程的异常，其他异常继续向上抛出
+func niceCallHandler(handler HandlerFunc, r *Request) {
+	defer func() {
+		if e := recover(); e != nil {
+			switch e {
+			case exceptionExit, exceptionExitAll:
+			default:
+				panic(e)
+			}
+		}
+	}()
+	handler(r)
+}
@@ -0,0 +1,282 @@
+package qhttp
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// 服务配置
+type ServerConfig struct {
+	Addr           string        // 监听地址，多个地址使用","分隔，例如 ":80,:8080"
+	ReadTimeout    time.Duration // 读取请求的超时时间
+	WriteTimeout   time.Duration // 写入返回的超时时间
+	IdleTimeout    time.Duration // keep-alive 连接的空闲超时时间
+	MaxHeaderBytes int           // 请求头的最大长度(字节)
+
+	FormParsingMemory int64 // 解析multipart表单时普通字段可使用的最大内存(字节)，上传文件始终写入临时文件
+	ClientMaxBodySize int64 // 请求内容的最大长度(字节)，超出时返回413，小于等于0表示不限制
+	UploadMaxFileSize int64 // 单个上传文件的最大长度(字节)，超出时返回413，小于等于0表示不限制
+
+	Compress *CompressConfig // 返回内容的压缩配置，为nil时不压缩，分组中可以通过Compress单独配置
+
+	TrustedProxies []string // 可信代理的IP或者CIDR网段，只有来自可信代理的请求才会解析代理请求头获取客户端IP
+
+	ServerRoot      string       // 静态文件服务的根目录，静态文件不经过中间件，只执行HOOK方法
+	SearchPaths     []string     // 静态文件的检索目录，在根目录之后按照顺序检索
+	StaticPaths     []StaticPath // 静态目录映射，例如: /assets => ./public/assets
+	IndexFiles      []string     // 访问目录时默认输出的文件
+	IndexFolder     bool         // 目录中没有默认文件时是否列出目录内容
+	RouteOverStatic bool         // 路由是否优先于静态文件，默认静态文件存在时优先输出静态文件
+	StaticHidden    bool         // 是否允许访问以"."开头的隐藏文件以及目录，默认不允许
+
+	CookieMaxAge time.Duration // Cookie的默认有效期
+	CookiePath   string        // Cookie的默认路径
+	CookieDomain string        // Cookie的默认域名，为空时使用请求的域名
+
+	SessionMaxAge  time.Duration  // 会话的有效期，每次访问会话时刷新
+	SessionIdName  string         // 会话ID在Cookie以及请求头中的名称
+	SessionStorage SessionStorage // 会话存储，为空时使用内存存储
+
+	LogPath          string // 日志目录，为空时访问日志输出到标准输出，错误日志输出到标准错误
+	AccessLogEnabled bool   // 是否开启访问日志
+	AccessLogFile    string // 访问日志的文件名称
+	AccessLogFormat  string // 访问日志格式: combined、json或者自定义模板
+	ErrorLogEnabled  bool   // 是否开启错误日志
+	ErrorLogFile     string // 错误日志的文件名称
+	LogRotateSize    int64  // 日志文件的切分大小(字节)，小于等于0时不切分
+	LogRotateBackups int    // 切分后保留的备份文件数量
+
+	Graceful        bool          // 是否开启平滑重启，开启后进程收到SIGUSR2信号时平滑重启所有服务
+	GracefulTimeout time.Duration // 平滑重启时等待正在处理的请求结束的最长时间
+
+	HTTPSAddr      string             // HTTPS监听地址，多个地址使用","分隔，默认为":443"
+	HTTPSCertFile  string             // HTTPS证书文件，文件变化时自动重新加载
+	HTTPSKeyFile   string             // HTTPS私钥文件
+	HTTPSRedirect  bool               // 是否将HTTP请求重定向到HTTPS地址
+	TLSConfig      *tls.Config        // 自定义的TLS配置，证书以及客户端认证配置会覆盖其中的对应项
+	ClientCAFile   string             // 用于验证客户端证书的CA证书文件
+	ClientAuthType tls.ClientAuthType // 客户端证书的认证方式
+
+	WebSocketCompression bool                  // 是否支持WebSocket的permessage-deflate压缩
+	WebSocketReadLimit   int64                 // WebSocket单个消息的最大长度(字节)，小于等于0时不限制消息长度，单个数据帧最大为1GB
+	WebSocketCheckOrigin func(r *Request) bool // WebSocket握手时的来源检查，为空时只允许同源请求
+}
+
+// 静态目录映射
+type StaticPath struct {
+	Prefix string // URI前缀
+	Path   string // 对应的本地目录
+}
+
+// 默认的服务配置
+var defaultServerConfig = ServerConfig{
+	Addr:           DEFAULT_ADDR,
+	ReadTimeout:    60 * time.Second,
+	WriteTimeout:   60 * time.Second,
+	IdleTimeout:    60 * time.Second,
+	MaxHeaderBytes: 10240,
+
+	FormParsingMemory: 1024 * 1024,
+	ClientMaxBodySize: 8 * 1024 * 1024,
+
+	IndexFiles: []string{"index.html", "index.htm"},
+
+	CookieMaxAge: 365 * 24 * time.Hour,
+	CookiePath:   "/",
+
+	SessionMaxAge: 24 * time.Hour,
+	SessionIdName: "qsessionid",
+
+	AccessLogFile:    "access.log",
+	AccessLogFormat:  LOG_FORMAT_COMBINED,
+	ErrorLogEnabled:  true,
+	ErrorLogFile:     "error.log",
+	LogRotateSize:    100 * 1024 * 1024,
+	LogRotateBackups: 10,
+
+	GracefulTimeout: 60 * time.Second,
+
+	WebSocketReadLimit: 16 * 1024 * 1024,
+}
+
+// 获取一份默认的服务配置
+func Config() ServerConfig {
+	return defaultServerConfig
+}
+
+// 设置服务配置，需要在服务启动之前调用，可信代理格式错误时会panic
+func (s *Server) SetConfig(c ServerConfig) {
+	if c.Addr == "" {
+		c.Addr = DEFAULT_ADDR
+	}
+	trusted, err := parseTrustedProxies(c.TrustedProxies)
+	if err != nil {
+		panic(err)
+	}
+	s.config = c
+	s.trusted = trusted
+}
+
+// 设置监听地址，多个地址可以传入多个参数或者使用","分隔
+func (s *Server) SetAddr(addr ...string) {
+	s.config.Addr = strings.Join(addr, ",")
+}
+
+// 设置监听端口，可以同时监听多个端口
+func (s *Server) SetPort(port ...int) {
+	addrs := make([]string, len(port))
+	for i, p := range port {
+		addrs[i] = fmt.Sprintf(":%d", p)
+	}
+	s.config.Addr = strings.Join(addrs, ",")
+}
+
+// 设置读取请求的超时时间
+func (s *Server) SetReadTimeout(t time.Duration) {
+	s.config.ReadTimeout = t
+}
+
+// 设置写入返回的超时时间
+func (s *Server) SetWriteTimeout(t time.Duration) {
+	s.config.WriteTimeout = t
+}
+
+// 设置keep-alive连接的空闲超时时间
+func (s *Server) SetIdleTimeout(t time.Duration) {
+	s.config.IdleTimeout = t
+}
+
+// 设置请求头的最大长度
+func (s *Server) SetMaxHeaderBytes(b int) {
+	s.config.MaxHeaderBytes = b
+}
+
+// 设置解析multipart表单时普通字段可使用的最大内存(字节)
+func (s *Server) SetFormParsingMemory(size int64) {
+	s.config.FormParsingMemory = size
+}
+
+// 设置请求内容的最大长度(字节)，小于等于0表示不限制
+func (s *Server) SetClientMaxBodySize(size int64) {
+	s.config.ClientMaxBodySize = size
+}
+
+// 设置单个上传文件的最大长度(字节)，小于等于0表示不限制
+func (s *Server) SetUploadMaxFileSize(size int64) {
+	s.config.UploadMaxFileSize = size
+}
+
+// 设置Cookie的默认有效期
+func (s *Server) SetCookieMaxAge(age time.Duration) {
+	s.config.CookieMaxAge = age
+}
+
+// 设置Cookie的默认路径
+func (s *Server) SetCookiePath(path string) {
+	s.config.CookiePath = path
+}
+
+// 设置Cookie的默认域名
+func (s *Server) SetCookieDomain(domain string) {
+	s.config.CookieDomain = domain
+}
+
+// 设置会话的有效期
+func (s *Server) SetSessionMaxAge(age time.Duration) {
+	s.config.SessionMaxAge = age
+}
+
+// 设置会话ID在Cookie以及请求头中的名称
+func (s *Server) SetSessionIdName(name string) {
+	s.config.SessionIdName = name
+}
+
+// 设置会话存储
+func (s *Server) SetSessionStorage(storage SessionStorage) {
+	s.config.SessionStorage = storage
+}
+
+// 设置是否开启平滑重启
+func (s *Server) SetGraceful(enabled bool) {
+	s.config.Graceful = enabled
+}
+
+// 设置平滑重启时等待正在处理的请求结束的最长时间
+func (s *Server) SetGracefulTimeout(t time.Duration) {
+	s.config.GracefulTimeout = t
+}
+
+// 设置是否支持WebSocket的permessage-deflate压缩
+func (s *Server) SetWebSocketCompression(enabled bool) {
+	s.config.WebSocketCompression = enabled
+}
+
+// 设置WebSocket单个消息的最大长度(字节)
+func (s *Server) SetWebSocketReadLimit(limit int64) {
+	s.config.WebSocketReadLimit = limit
+}
+
+// 设置WebSocket握手时的来源检查方法
+func (s *Server) SetWebSocketCheckOrigin(f func(r *Request) bool) {
+	s.config.WebSocketCheckOrigin = f
+}
+
+// 设置可信代理，参数为IP或者CIDR网段，例如: 10.0.0.0/8、127.0.0.1
+func (s *Server) SetTrustedProxies(proxies ...string) error {
+	trusted, err := parseTrustedProxies(proxies)
+	if err != nil {
+		return err
+	}
+	s.config.TrustedProxies = proxies
+	s.trusted = trusted
+	return nil
+}
+
+// 解析可信代理列表，单个IP转换为只包含该IP的网段
+func parseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	trusted := make([]*net.IPNet, 0, len(proxies))
+	for _, v := range proxies {
+		v = strings.TrimSpace(v)
+		if !strings.Contains(v, "/") {
+			ip := net.ParseIP(v)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy: %s", v)
+			}
+			if ip4 := ip.To4(); ip4 != nil {
+				ip = ip4
+			}
+			trusted = append(trusted, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy: %s", v)
+		}
+		trusted = append(trusted, ipNet)
+	}
+	return trusted, nil
+}
+
+// 判断IP是否属于可信代理
+func (s *Server) isTrustedProxy(ip net.IP) bool {
+	for _, v := range s.trusted {
+		if v.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// 解析配置中的监听地址列表
+func (s *Server) addrs() []string {
+	addrs := make([]string, 0)
+	for _, v := range strings.Split(s.config.Addr, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			addrs = append(addrs, v)
+		}
+	}
+	return addrs
+}
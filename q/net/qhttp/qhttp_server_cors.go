@@ -0,0 +1,212 @@
+package qhttp
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 跨域请求(CORS)配置
+type CORSConfig struct {
+	AllowOrigins     []string                             // 允许的来源，支持完整来源(https://a.com)、域名(a.com)、子域名通配(*.a.com)以及"*"
+	AllowOriginFunc  func(r *Request, origin string) bool // 自定义的来源检查，AllowOrigins不匹配时调用
+	AllowMethods     []string                             // 允许的请求方法，为空时使用默认方法
+	AllowHeaders     []string                             // 允许的请求头，为空时使用默认请求头，"*"表示允许所有请求头
+	ExposeHeaders    []string                             // 允许客户端读取的返回头
+	AllowCredentials bool                                 // 是否允许携带Cookie等凭证，开启时不会返回"*"作为允许的来源
+	MaxAge           time.Duration                        // 预检请求结果的缓存时间，为0时不设置
+}
+
+// 默认允许的跨域请求方法
+var defaultCORSMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
+
+// 默认允许的跨域请求头
+var defaultCORSHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With"}
+
+// 设置分组中注册路由的跨域配置，只对之后在分组中注册的路由生效
+func (g *RouterGroup) CORS(config ...CORSConfig) *RouterGroup {
+	return g.Middleware(MiddlewareCORS(config...))
+}
+
+// 处理跨域请求的中间件，不传递配置时允许所有来源。
+// 预检请求由中间件直接返回，路由只注册了其他方法时服务同样会按照实际请求的方法执行中间件响应预检请求。
+func MiddlewareCORS(config ...CORSConfig) HandlerFunc {
+	c := CORSConfig{}
+	if len(config) > 0 {
+		c = config[0]
+	}
+	if len(c.AllowMethods) == 0 {
+		c.AllowMethods = defaultCORSMethods
+	}
+	if len(c.AllowHeaders) == 0 {
+		c.AllowHeaders = defaultCORSHeaders
+	}
+	if len(c.AllowOrigins) == 0 && c.AllowOriginFunc == nil {
+		c.AllowOrigins = []string{"*"}
+	}
+	return func(r *Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" || isSameOrigin(r, origin) {
+			r.Middleware.Next()
+			return
+		}
+		header := r.Response.Header()
+		addVary(header, "Origin")
+		preflight := isPreflight(r)
+		if !c.allowOrigin(r, origin) {
+			if preflight {
+				r.Response.WriteStatus(http.StatusForbidden)
+				return
+			}
+			r.Middleware.Next()
+			return
+		}
+		if c.AllowCredentials || !containsString(c.AllowOrigins, "*") {
+			header.Set("Access-Control-Allow-Origin", origin)
+		} else {
+			header.Set("Access-Control-Allow-Origin", "*")
+		}
+		if c.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+		if !preflight {
+			if len(c.ExposeHeaders) > 0 {
+				header.Set("Access-Control-Expose-Headers", strings.Join(c.ExposeHeaders, ", "))
+			}
+			r.Middleware.Next()
+			return
+		}
+		addVary(header, "Access-Control-Request-Method")
+		addVary(header, "Access-Control-Request-Headers")
+		method := strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))
+		requestHeaders := r.Header.Get("Access-Control-Request-Headers")
+		if !containsString(c.AllowMethods, method) || !c.allowHeaders(requestHeaders) {
+			header.Del("Access-Control-Allow-Origin")
+			header.Del("Access-Control-Allow-Credentials")
+			r.Response.WriteStatus(http.StatusForbidden)
+			return
+		}
+		header.Set("Access-Control-Allow-Methods", strings.Join(c.AllowMethods, ", "))
+		if containsString(c.AllowHeaders, "*") {
+			if requestHeaders != "" {
+				header.Set("Access-Control-Allow-Headers", requestHeaders)
+			}
+		} else {
+			header.Set("Access-Control-Allow-Headers", strings.Join(c.AllowHeaders, ", "))
+		}
+		if c.MaxAge > 0 {
+			header.Set("Access-Control-Max-Age", strconv.Itoa(int(c.MaxAge/time.Second)))
+		}
+		r.Response.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// 判断来源是否允许跨域访问
+func (c *CORSConfig) allowOrigin(r *Request, origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	host := parseHost(u.Host)
+	for _, v := range c.AllowOrigins {
+		switch {
+		case v == "*":
+			return true
+		case strings.Contains(v, "://"):
+			if strings.EqualFold(strings.TrimSuffix(v, "/"), origin) {
+				return true
+			}
+		case strings.HasPrefix(v, "*."):
+			if strings.HasSuffix(host, strings.ToLower(v[1:])) {
+				return true
+			}
+		case strings.EqualFold(v, host):
+			return true
+		}
+	}
+	return c.AllowOriginFunc != nil && c.AllowOriginFunc(r, origin)
+}
+
+// 判断预检请求中的请求头是否全部允许
+func (c *CORSConfig) allowHeaders(requestHeaders string) bool {
+	if containsString(c.AllowHeaders, "*") {
+		return true
+	}
+	for _, v := range strings.Split(requestHeaders, ",") {
+		if v = strings.TrimSpace(v); v != "" && !containsString(c.AllowHeaders, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// 判断来源与请求的协议、域名以及端口是否相同，同源请求不需要跨域处理
+func isSameOrigin(r *Request, origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	scheme := "http"
+	if r.IsHTTPS() {
+		scheme = "https"
+	}
+	return strings.EqualFold(u.Scheme, scheme) && originAddr(u.Host, scheme) == originAddr(r.Host, scheme)
+}
+
+// 获取带端口号的地址，没有端口号时使用协议的默认端口
+func originAddr(host, scheme string) string {
+	port := "80"
+	if scheme == "https" {
+		port = "443"
+	}
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		host, port = h, p
+	}
+	return net.JoinHostPort(strings.ToLower(strings.Trim(host, "[]")), port)
+}
+
+// 判断请求是否为跨域预检请求
+func isPreflight(r *Request) bool {
+	return r.Method == http.MethodOptions &&
+		r.Header.Get("Origin") != "" &&
+		r.Header.Get("Access-Control-Request-Method") != ""
+}
+
+// 预检请求没有匹配到OPTIONS路由时，按照实际请求的方法匹配路由，
+// 执行路由的中间件响应预检请求，处理方法替换为返回405，中间件没有响应时与普通的方法不匹配一致。
+func (s *Server) searchPreflightHandler(r *Request, allowed []string) (*handlerItem, map[string][]string) {
+	if len(allowed) == 0 || !isPreflight(r) {
+		return nil, nil
+	}
+	method := strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))
+	item, vars, _ := s.searchHandler(method, r.URL.Path, r.parsedHost)
+	if item == nil {
+		return nil, nil
+	}
+	preflight := *item
+	preflight.handler = func(r *Request) {
+		r.Response.Header().Set("Allow", strings.Join(allowed, ", "))
+		r.Response.WriteStatus(http.StatusMethodNotAllowed)
+	}
+	return &preflight, vars
+}
+
+// 添加Vary返回头，已经存在时不重复添加
+func addVary(header http.Header, name string) {
+	if !headerContainsToken(header, "Vary", name) {
+		header.Add("Vary", name)
+	}
+}
+
+// 判断数组中是否包含指定的字符串(不区分大小写)
+func containsString(array []string, s string) bool {
+	for _, v := range array {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
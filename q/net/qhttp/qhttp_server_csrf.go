@@ -0,0 +1,121 @@
+package qhttp
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"grt/q/utils/random"
+)
+
+// CSRF令牌的存储方式
+const (
+	CSRF_STORAGE_SESSION = "session" // 令牌保存在会话中
+	CSRF_STORAGE_COOKIE  = "cookie"  // 令牌保存在Cookie中，请求时通过请求头或者表单再提交一次(双重提交)
+)
+
+// CSRF令牌的长度
+const csrfTokenLength = 32
+
+// CSRF令牌在会话中的名称
+const csrfSessionKey = "_csrf_token"
+
+// CSRF防护配置
+type CSRFConfig struct {
+	Storage    string // 令牌的存储方式，默认为CSRF_STORAGE_SESSION
+	CookieName string // 双重提交模式下保存令牌的Cookie名称，默认为"qcsrf"
+	HeaderName string // 提交令牌的请求头名称，默认为"X-CSRF-Token"
+	FieldName  string // 提交令牌的表单字段名称，请求头中没有令牌时使用，默认为"_csrf"
+}
+
+// 默认的CSRF防护配置
+var defaultCSRFConfig = CSRFConfig{
+	Storage:    CSRF_STORAGE_SESSION,
+	CookieName: "qcsrf",
+	HeaderName: "X-CSRF-Token",
+	FieldName:  "_csrf",
+}
+
+// 设置分组中注册路由的CSRF防护，只对之后在分组中注册的路由生效
+func (g *RouterGroup) CSRF(config ...CSRFConfig) *RouterGroup {
+	return g.Middleware(MiddlewareCSRF(config...))
+}
+
+// CSRF防护中间件，GET、HEAD、OPTIONS以及TRACE以外的请求需要通过请求头或者表单提交令牌，
+// 令牌不正确时返回403。双重提交模式下安全方法的请求会自动下发令牌Cookie。
+func MiddlewareCSRF(config ...CSRFConfig) HandlerFunc {
+	c := defaultCSRFConfig
+	if len(config) > 0 {
+		if config[0].Storage != "" {
+			c.Storage = config[0].Storage
+		}
+		if config[0].CookieName != "" {
+			c.CookieName = config[0].CookieName
+		}
+		if config[0].HeaderName != "" {
+			c.HeaderName = config[0].HeaderName
+		}
+		if config[0].FieldName != "" {
+			c.FieldName = config[0].FieldName
+		}
+	}
+	return func(r *Request) {
+		r.csrf = &c
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+			if c.Storage == CSRF_STORAGE_COOKIE {
+				r.CSRFToken()
+			}
+		default:
+			expected := r.csrfStoredToken()
+			actual := r.Header.Get(c.HeaderName)
+			if actual == "" {
+				actual = r.GetFormString(c.FieldName)
+			}
+			if expected == "" || subtle.ConstantTimeCompare([]byte(expected), []byte(actual)) != 1 {
+				r.Response.WriteStatus(http.StatusForbidden)
+				return
+			}
+		}
+		r.Middleware.Next()
+	}
+}
+
+// 获取当前请求的CSRF令牌，用于输出到页面表单或者返回给客户端，令牌不存在时生成新的令牌
+func (r *Request) CSRFToken() string {
+	if token := r.csrfStoredToken(); token != "" {
+		return token
+	}
+	return r.RotateCSRFToken()
+}
+
+// 重新生成CSRF令牌，用于登录等权限变化的场景，返回新的令牌。
+// 会话模式下调用Session.RegenerateId时令牌同样会失效。
+func (r *Request) RotateCSRFToken() string {
+	token := random.SecureStr(csrfTokenLength)
+	c := r.csrfConfig()
+	if c.Storage == CSRF_STORAGE_COOKIE {
+		config := r.Server.config
+		r.Cookie.SetCookie(c.CookieName, token, r.Cookie.defaultDomain(), config.CookiePath,
+			0, false, r.IsHTTPS(), http.SameSiteLaxMode)
+	} else {
+		r.Session.Set(csrfSessionKey, token)
+	}
+	return token
+}
+
+// 获取已经保存的CSRF令牌
+func (r *Request) csrfStoredToken() string {
+	c := r.csrfConfig()
+	if c.Storage == CSRF_STORAGE_COOKIE {
+		return r.Cookie.Get(c.CookieName)
+	}
+	return r.Session.GetString(csrfSessionKey)
+}
+
+// 获取当前请求的CSRF防护配置，没有经过CSRF中间件时使用默认配置
+func (r *Request) csrfConfig() *CSRFConfig {
+	if r.csrf != nil {
+		return r.csrf
+	}
+	return &defaultCSRFConfig
+}
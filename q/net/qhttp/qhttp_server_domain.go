@@ -0,0 +1,64 @@
+package qhttp
+
+import "strings"
+
+// 域名对象，通过域名对象注册的路由只对绑定的域名生效
+type Domain struct {
+	server  *Server  // 关联的服务器对象
+	domains []string // 绑定的域名列表
+}
+
+// 创建域名对象，多个域名使用","分隔，例如: a.com,b.com
+func (s *Server) Domain(domains string) *Domain {
+	d := &Domain{
+		server:  s,
+		domains: make([]string, 0),
+	}
+	for _, v := range strings.Split(domains, ",") {
+		if v = strings.ToLower(strings.TrimSpace(v)); v != "" {
+			d.domains = append(d.domains, v)
+		}
+	}
+	return d
+}
+
+// 在域名上绑定路由规则与处理方法
+func (d *Domain) BindHandler(pattern string, handler HandlerFunc) {
+	for _, domain := range d.domains {
+		d.server.BindHandler(pattern+"@"+domain, handler)
+	}
+}
+
+// 在域名上按照路由规则绑定中间件
+func (d *Domain) BindMiddleware(pattern string, handlers ...HandlerFunc) {
+	for _, domain := range d.domains {
+		d.server.BindMiddleware(pattern+"@"+domain, handlers...)
+	}
+}
+
+// 在域名上绑定对象
+func (d *Domain) BindObject(pattern string, object interface{}, methods ...string) {
+	for _, domain := range d.domains {
+		d.server.bindObject(pattern, domain, object, nil, methods...)
+	}
+}
+
+// 在域名上绑定REST对象
+func (d *Domain) BindObjectRest(pattern string, object interface{}) {
+	for _, domain := range d.domains {
+		d.server.bindObjectRest(pattern, domain, object, nil)
+	}
+}
+
+// 在域名上创建路由分组
+func (d *Domain) Group(prefix string, groups ...func(g *RouterGroup)) *RouterGroup {
+	g := &RouterGroup{
+		server: d.server,
+		domain: d,
+		prefix: "/" + strings.Trim(prefix, "/"),
+	}
+	for _, f := range groups {
+		f(g)
+	}
+	return g
+}
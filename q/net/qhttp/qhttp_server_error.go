@@ -0,0 +1,124 @@
+package qhttp
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+)
+
+// 错误处理方法，<err>为请求处理过程中产生的异常
+type ErrorHandlerFunc = func(r *Request, err error)
+
+// 设置错误处理方法，请求处理过程中产生异常时调用，
+// 调用之前返回缓冲区已经清空并且状态码已经设置为500
+func (s *Server) SetErrorHandler(handler ErrorHandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errorHandler = handler
+}
+
+// 绑定状态码对应的处理方法，返回的状态码与<status>一致时清空返回内容并执行该方法，
+// 用于自定义404、500等状态页面
+func (s *Server) BindStatusHandler(status int, handler HandlerFunc) {
+	s.bindStatusHandler(status, "", handler)
+}
+
+// 批量绑定状态码对应的处理方法
+func (s *Server) BindStatusHandlerByMap(handlerMap map[int]HandlerFunc) {
+	for status, handler := range handlerMap {
+		s.BindStatusHandler(status, handler)
+	}
+}
+
+// 在域名上绑定状态码对应的处理方法，优先于服务绑定的处理方法
+func (d *Domain) BindStatusHandler(status int, handler HandlerFunc) {
+	for _, domain := range d.domains {
+		d.server.bindStatusHandler(status, domain, handler)
+	}
+}
+
+// 在域名上批量绑定状态码对应的处理方法
+func (d *Domain) BindStatusHandlerByMap(handlerMap map[int]HandlerFunc) {
+	for status, handler := range handlerMap {
+		d.BindStatusHandler(status, handler)
+	}
+}
+
+// 绑定状态码对应的处理方法，键名格式为: 状态码@域名
+func (s *Server) bindStatusHandler(status int, domain string, handler HandlerFunc) {
+	if domain == "" {
+		domain = DEFAULT_DOMAIN
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.statusHandlers == nil {
+		s.statusHandlers = make(map[string]HandlerFunc)
+	}
+	s.statusHandlers[strconv.Itoa(status)+"@"+domain] = handler
+}
+
+// 获取状态码对应的处理方法，域名绑定的处理方法优先
+func (s *Server) searchStatusHandler(status int, host string) HandlerFunc {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, domain := range []string{host, DEFAULT_DOMAIN} {
+		if handler, ok := s.statusHandlers[strconv.Itoa(status)+"@"+domain]; ok {
+			return handler
+		}
+	}
+	return nil
+}
+
+// 执行返回状态码对应的处理方法，返回头已经输出时不执行
+func (s *Server) callStatusHandler(r *Request) {
+	if r.Response.wroteHeader || r.Response.hijacked || r.statusHandled {
+		return
+	}
+	handler := s.searchStatusHandler(r.Response.Status, r.parsedHost)
+	if handler == nil {
+		return
+	}
+	r.statusHandled = true
+	r.Response.ClearBuffer()
+	niceCallHandler(handler, r)
+}
+
+// 处理请求流程中未捕获的异常，记录带有请求ID以及调用栈的错误日志，
+// 设置了错误处理方法时交给错误处理方法，否则输出500状态码对应的页面，已经输出过返回头时只记录日志
+func (s *Server) handlePanic(r *Request, e interface{}, stack []byte) {
+	s.handleErrorLog(r, e, stack)
+	if r.Response.wroteHeader || r.Response.hijacked || r.statusHandled {
+		return
+	}
+	r.Response.ClearBuffer()
+	r.Response.WriteHeader(http.StatusInternalServerError)
+	s.mu.RLock()
+	handler := s.errorHandler
+	s.mu.RUnlock()
+	if handler != nil {
+		r.statusHandled = true
+		if s.niceCallErrorHandler(handler, r, e) {
+			return
+		}
+		r.Response.ClearBuffer()
+	}
+	r.Response.WriteStatus(http.StatusInternalServerError)
+	s.callStatusHandler(r)
+}
+
+// 执行错误处理方法，错误处理方法本身产生异常时只记录日志，返回是否执行成功
+func (s *Server) niceCallErrorHandler(handler ErrorHandlerFunc, r *Request, e interface{}) (ok bool) {
+	defer func() {
+		if e := recover(); e != nil {
+			s.handleErrorLog(r, e, debug.Stack())
+			ok = false
+		}
+	}()
+	err, isErr := e.(error)
+	if !isErr {
+		err = fmt.Errorf("%v", e)
+	}
+	handler(r, err)
+	return true
+}
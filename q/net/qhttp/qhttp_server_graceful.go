@@ -0,0 +1,68 @@
+package qhttp
+
+import (
+	"encoding/json"
+	"log"
+	"net"
+	"os"
+	"sync"
+)
+
+// 平滑重启时传递给子进程的环境变量名称，值为服务名称与监听对象列表的JSON映射
+const gracefulEnvKey = "QHTTP_GRACEFUL_LISTENERS"
+
+// 传递给子进程的监听对象
+type gracefulListener struct {
+	Fd    int  `json:"fd"`    // 子进程中的文件描述符
+	Https bool `json:"https"` // 是否为HTTPS监听
+}
+
+var (
+	// 从父进程继承的监听对象，键名为服务名称
+	inherited map[string][]*serverListener
+	// 继承的监听对象只解析一次
+	inheritedOnce sync.Once
+	// 继承的监听对象的并发控制
+	inheritedMu sync.Mutex
+)
+
+// 获取服务从父进程继承的监听对象，每个服务只能获取一次
+func inheritedListeners(name string) []*serverListener {
+	inheritedOnce.Do(parseInheritedListeners)
+	inheritedMu.Lock()
+	defer inheritedMu.Unlock()
+	listeners := inherited[name]
+	delete(inherited, name)
+	return listeners
+}
+
+// 解析环境变量中从父进程继承的监听对象
+func parseInheritedListeners() {
+	value := os.Getenv(gracefulEnvKey)
+	if value == "" {
+		return
+	}
+	os.Unsetenv(gracefulEnvKey)
+	listenerMap := make(map[string][]gracefulListener)
+	if err := json.Unmarshal([]byte(value), &listenerMap); err != nil {
+		log.Printf("[qhttp] parse inherited listeners failed: %v", err)
+		return
+	}
+	inherited = make(map[string][]*serverListener)
+	for name, items := range listenerMap {
+		for _, item := range items {
+			file := os.NewFile(uintptr(item.Fd), name)
+			ln, err := net.FileListener(file)
+			file.Close()
+			if err != nil {
+				log.Printf("[qhttp] inherit listener %d of server '%s' failed: %v", item.Fd, name, err)
+				continue
+			}
+			inherited[name] = append(inherited[name], &serverListener{
+				Listener: ln,
+				addr:     ln.Addr().String(),
+				https:    item.Https,
+			})
+		}
+	}
+}
@@ -0,0 +1,101 @@
+//go:build !windows
+// +build !windows
+
+package qhttp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// 平滑重启信号处理只注册一次
+var gracefulSignalOnce sync.Once
+
+// 平滑重启所有运行中的服务: 将所有监听对象的文件描述符传递给新启动的子进程，
+// 子进程使用相同的参数启动并直接使用继承的监听对象，当前进程停止接收新的连接并在请求处理完成后关闭服务
+func RestartAllServers() error {
+	servers := runningServers()
+	if len(servers) == 0 {
+		return fmt.Errorf("no running server to restart")
+	}
+	var (
+		files = make([]*os.File, 0)
+		fdMap = make(map[string][]gracefulListener)
+	)
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+	for _, s := range servers {
+		s.mu.RLock()
+		listeners := s.listeners
+		s.mu.RUnlock()
+		for _, ln := range listeners {
+			tcpLn, ok := ln.Listener.(*net.TCPListener)
+			if !ok {
+				return fmt.Errorf("listener of server '%s' does not support graceful restart", s.name)
+			}
+			f, err := tcpLn.File()
+			if err != nil {
+				return err
+			}
+			files = append(files, f)
+			// 子进程中ExtraFiles的文件描述符从3开始
+			fdMap[s.name] = append(fdMap[s.name], gracefulListener{
+				Fd:    2 + len(files),
+				Https: ln.https,
+			})
+		}
+	}
+	value, err := json.Marshal(fdMap)
+	if err != nil {
+		return err
+	}
+	path, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(path, os.Args[1:]...)
+	cmd.Env = append(os.Environ(), gracefulEnvKey+"="+string(value))
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.ExtraFiles = files
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	for _, s := range servers {
+		ctx, cancel := context.WithTimeout(context.Background(), s.config.GracefulTimeout)
+		if err := s.Shutdown(ctx); err != nil {
+			log.Printf("[qhttp] graceful shutdown server '%s' failed: %v", s.name, err)
+		}
+		cancel()
+	}
+	return nil
+}
+
+// 注册平滑重启的信号处理，收到SIGUSR2信号时平滑重启所有服务
+func handleGracefulSignal() {
+	gracefulSignalOnce.Do(func() {
+		ch := make(chan os.Signal, 1)
+		signal.Notify(ch, syscall.SIGUSR2)
+		go func() {
+			for range ch {
+				start := time.Now()
+				if err := RestartAllServers(); err != nil {
+					log.Printf("[qhttp] graceful restart failed: %v", err)
+					continue
+				}
+				log.Printf("[qhttp] process %d restarted gracefully in %s", os.Getpid(), time.Since(start))
+			}
+		}()
+	})
+}
@@ -0,0 +1,14 @@
+//go:build windows
+// +build windows
+
+package qhttp
+
+import "errors"
+
+// Windows不支持传递监听对象的平滑重启
+func RestartAllServers() error {
+	return errors.New("graceful restart is not supported on windows")
+}
+
+// Windows没有SIGUSR2信号，不做处理
+func handleGracefulSignal() {}
@@ -0,0 +1,129 @@
+package qhttp
+
+import "strings"
+
+// 路由分组对象，分组中注册的路由使用相同的前缀以及分组中间件
+type RouterGroup struct {
+	server     *Server       // 关联的服务器对象
+	domain     *Domain       // 关联的域名对象，为空表示所有域名
+	prefix     string        // 路由前缀
+	middleware []HandlerFunc // 分组中间件
+}
+
+// 创建路由分组，<groups>中可以直接注册分组的中间件以及路由
+func (s *Server) Group(prefix string, groups ...func(g *RouterGroup)) *RouterGroup {
+	g := &RouterGroup{
+		server: s,
+		prefix: "/" + strings.Trim(prefix, "/"),
+	}
+	for _, f := range groups {
+		f(g)
+	}
+	return g
+}
+
+// 创建子分组，子分组继承当前分组的前缀、域名以及中间件
+func (g *RouterGroup) Group(prefix string, groups ...func(g *RouterGroup)) *RouterGroup {
+	child := &RouterGroup{
+		server:     g.server,
+		domain:     g.domain,
+		prefix:     g.uri(prefix),
+		middleware: append([]HandlerFunc(nil), g.middleware...),
+	}
+	for _, f := range groups {
+		f(child)
+	}
+	return child
+}
+
+// 添加分组中间件，只对之后在分组中注册的路由生效
+func (g *RouterGroup) Middleware(handlers ...HandlerFunc) *RouterGroup {
+	g.middleware = append(g.middleware, handlers...)
+	return g
+}
+
+// 注册所有HTTP方法的路由
+func (g *RouterGroup) ALL(pattern string, handler HandlerFunc) *RouterGroup {
+	return g.bind("", pattern, handler)
+}
+
+// 注册GET方法的路由
+func (g *RouterGroup) GET(pattern string, handler HandlerFunc) *RouterGroup {
+	return g.bind("GET", pattern, handler)
+}
+
+// 注册POST方法的路由
+func (g *RouterGroup) POST(pattern string, handler HandlerFunc) *RouterGroup {
+	return g.bind("POST", pattern, handler)
+}
+
+// 注册PUT方法的路由
+func (g *RouterGroup) PUT(pattern string, handler HandlerFunc) *RouterGroup {
+	return g.bind("PUT", pattern, handler)
+}
+
+// 注册DELETE方法的路由
+func (g *RouterGroup) DELETE(pattern string, handler HandlerFunc) *RouterGroup {
+	return g.bind("DELETE", pattern, handler)
+}
+
+// 注册PATCH方法的路由
+func (g *RouterGroup) PATCH(pattern string, handler HandlerFunc) *RouterGroup {
+	return g.bind("PATCH", pattern, handler)
+}
+
+// 注册HEAD方法的路由
+func (g *RouterGroup) HEAD(pattern string, handler HandlerFunc) *RouterGroup {
+	return g.bind("HEAD", pattern, handler)
+}
+
+// 注册OPTIONS方法的路由
+func (g *RouterGroup) OPTIONS(pattern string, handler HandlerFunc) *RouterGroup {
+	return g.bind("OPTIONS", pattern, handler)
+}
+
+// 在分组中绑定对象，规则与Server.BindObject一致
+func (g *RouterGroup) Object(pattern string, object interface{}, methods ...string) *RouterGroup {
+	for _, domain := range g.domains() {
+		g.server.bindObject(g.uri(pattern), domain, object, g.copyMiddleware(), methods...)
+	}
+	return g
+}
+
+// 在分组中绑定REST对象，规则与Server.BindObjectRest一致
+func (g *RouterGroup) REST(pattern string, object interface{}) *RouterGroup {
+	for _, domain := range g.domains() {
+		g.server.bindObjectRest(g.uri(pattern), domain, object, g.copyMiddleware())
+	}
+	return g
+}
+
+// 在分组中注册路由
+func (g *RouterGroup) bind(method, pattern string, handler HandlerFunc) *RouterGroup {
+	uri := g.uri(pattern)
+	if method != "" {
+		uri = method + ":" + uri
+	}
+	for _, domain := range g.domains() {
+		g.server.bindObjectHandler(uri, domain, handler, g.copyMiddleware())
+	}
+	return g
+}
+
+// 拼接分组前缀与路由规则
+func (g *RouterGroup) uri(pattern string) string {
+	return strings.TrimRight(g.prefix, "/") + "/" + strings.TrimLeft(pattern, "/")
+}
+
+// 获取分组绑定的域名列表，未绑定域名时返回一个空的域名
+func (g *RouterGroup) domains() []string {
+	if g.domain == nil {
+		return []string{""}
+	}
+	return g.domain.domains
+}
+
+// 复制当前的分组中间件，避免之后添加的中间件影响已注册的路由
+func (g *RouterGroup) copyMiddleware() []HandlerFunc {
+	return append([]HandlerFunc(nil), g.middleware...)
+}
@@ -0,0 +1,90 @@
+package qhttp
+
+// 请求流程中的HOOK点
+const (
+	HOOK_BEFORE_SERVE  = "BeforeServe"  // 执行服务之前(中间件以及处理方法、静态文件)
+	HOOK_AFTER_SERVE   = "AfterServe"   // 执行服务之后
+	HOOK_BEFORE_OUTPUT = "BeforeOutput" // 输出返回内容之前
+	HOOK_AFTER_OUTPUT  = "AfterOutput"  // 输出返回内容之后
+	HOOK_BEFORE_CLOSE  = "BeforeClose"  // 请求结束之前
+)
+
+// 按照路由规则绑定HOOK方法，规则格式与BindHandler一致，
+// 同一HOOK点的方法按照注册顺序执行，调用ExitHook时后续的方法不再执行，
+// 调用ExitAll时当前以及之后所有HOOK点的方法都不再执行，但是BeforeClose始终会执行，可以用于释放资源
+func (s *Server) BindHookHandler(pattern string, hook string, handler HandlerFunc) {
+	s.bindHookHandler(pattern, "", hook, handler)
+}
+
+// 按照路由规则批量绑定HOOK方法，键名为HOOK点名称
+func (s *Server) BindHookHandlerByMap(pattern string, hookMap map[string]HandlerFunc) {
+	for hook, handler := range hookMap {
+		s.BindHookHandler(pattern, hook, handler)
+	}
+}
+
+// 绑定HOOK方法
+func (s *Server) bindHookHandler(pattern, domain, hook string, handler HandlerFunc) {
+	if domain != "" {
+		pattern += "@" + domain
+	}
+	if err := s.addItem(pattern, &handlerItem{
+		itemType: handlerTypeHook,
+		hookName: hook,
+		handler:  handler,
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// 在域名上按照路由规则绑定HOOK方法
+func (d *Domain) BindHookHandler(pattern string, hook string, handler HandlerFunc) {
+	for _, domain := range d.domains {
+		d.server.bindHookHandler(pattern, domain, hook, handler)
+	}
+}
+
+// 在分组中绑定HOOK方法
+func (g *RouterGroup) Hook(pattern string, hook string, handler HandlerFunc) *RouterGroup {
+	for _, domain := range g.domains() {
+		g.server.bindHookHandler(g.uri(pattern), domain, hook, handler)
+	}
+	return g
+}
+
+// 执行请求匹配的指定HOOK点的方法，请求流程已经退出时只执行BeforeClose
+func (s *Server) callHookHandler(hook string, r *Request) {
+	if r.exit && hook != HOOK_BEFORE_CLOSE {
+		return
+	}
+	if r.hooks == nil {
+		r.hooks = s.searchItems(handlerTypeHook, r.Method, r.URL.Path, r.parsedHost)
+	}
+	for _, item := range r.hooks {
+		if item.hookName != hook {
+			continue
+		}
+		if niceCallHookHandler(item.handler, r) {
+			return
+		}
+	}
+}
+
+// 执行HOOK方法，返回是否调用了ExitHook或者ExitAll退出当前HOOK点
+func niceCallHookHandler(handler HandlerFunc, r *Request) (exitHook bool) {
+	r.inHook = true
+	defer func() {
+		r.inHook = false
+		if e := recover(); e != nil {
+			switch e {
+			case exceptionExit:
+			case exceptionExitHook, exceptionExitAll:
+				exitHook = true
+			default:
+				panic(e)
+			}
+		}
+	}()
+	handler(r)
+	return false
+}
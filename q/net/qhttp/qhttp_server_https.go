@@ -0,0 +1,208 @@
+package qhttp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 默认的HTTPS监听地址
+const DEFAULT_HTTPS_ADDR = ":443"
+
+// 开启HTTPS，证书文件在磁盘上发生变化时会在新的连接中自动重新加载，
+// 可以通过<tlsConfig>自定义TLS配置，需要在服务启动之前调用
+func (s *Server) EnableHTTPS(certFile, keyFile string, tlsConfig ...*tls.Config) {
+	s.config.HTTPSCertFile = certFile
+	s.config.HTTPSKeyFile = keyFile
+	if len(tlsConfig) > 0 {
+		s.config.TLSConfig = tlsConfig[0]
+	}
+}
+
+// 设置HTTPS监听地址，多个地址可以传入多个参数或者使用","分隔
+func (s *Server) SetHTTPSAddr(addr ...string) {
+	s.config.HTTPSAddr = strings.Join(addr, ",")
+}
+
+// 设置HTTPS监听端口，可以同时监听多个端口
+func (s *Server) SetHTTPSPort(port ...int) {
+	addrs := make([]string, len(port))
+	for i, p := range port {
+		addrs[i] = fmt.Sprintf(":%d", p)
+	}
+	s.config.HTTPSAddr = strings.Join(addrs, ",")
+}
+
+// 设置是否将HTTP请求重定向到HTTPS地址
+func (s *Server) SetHTTPSRedirect(enabled bool) {
+	s.config.HTTPSRedirect = enabled
+}
+
+// 设置客户端证书认证，<caFile>为用于验证客户端证书的CA证书文件
+func (s *Server) SetClientAuth(caFile string, authType tls.ClientAuthType) {
+	s.config.ClientCAFile = caFile
+	s.config.ClientAuthType = authType
+}
+
+// 解析配置中的HTTPS监听地址列表
+func (s *Server) httpsAddrs() []string {
+	addr := s.config.HTTPSAddr
+	if addr == "" {
+		addr = DEFAULT_HTTPS_ADDR
+	}
+	addrs := make([]string, 0)
+	for _, v := range strings.Split(addr, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			addrs = append(addrs, v)
+		}
+	}
+	return addrs
+}
+
+// 生成HTTPS服务使用的TLS配置
+func (s *Server) newTLSConfig() (*tls.Config, error) {
+	if s.config.HTTPSCertFile == "" {
+		return nil, errors.New("https certificate is not configured")
+	}
+	reloader := &certReloader{
+		certFile: s.config.HTTPSCertFile,
+		keyFile:  s.config.HTTPSKeyFile,
+	}
+	if _, err := reloader.load(); err != nil {
+		return nil, err
+	}
+	config := &tls.Config{}
+	if s.config.TLSConfig != nil {
+		config = s.config.TLSConfig.Clone()
+	}
+	config.Certificates = nil
+	config.GetCertificate = reloader.GetCertificate
+	if len(config.NextProtos) == 0 {
+		config.NextProtos = []string{"h2", "http/1.1"}
+	}
+	if s.config.ClientCAFile != "" {
+		content, err := ioutil.ReadFile(s.config.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(content) {
+			return nil, fmt.Errorf("no certificate found in client ca file: %s", s.config.ClientCAFile)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = s.config.ClientAuthType
+	}
+	return config, nil
+}
+
+// 将HTTP请求重定向到HTTPS地址，返回是否已经重定向
+func (s *Server) redirectHTTPS(r *Request) bool {
+	if !s.config.HTTPSRedirect || s.config.HTTPSCertFile == "" || r.TLS != nil {
+		return false
+	}
+	host := r.parsedHost
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if addrs := s.ListenedHTTPSAddrs(); len(addrs) > 0 {
+		if _, port, err := net.SplitHostPort(addrs[0]); err == nil && port != "443" {
+			host += ":" + port
+		}
+	} else if addrs := s.httpsAddrs(); len(addrs) > 0 {
+		if _, port, err := net.SplitHostPort(addrs[0]); err == nil && port != "443" && port != "0" {
+			host += ":" + port
+		}
+	}
+	code := http.StatusMovedPermanently
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		code = http.StatusPermanentRedirect
+	}
+	r.Response.RedirectTo("https://"+host+r.URL.RequestURI(), code)
+	return true
+}
+
+// 证书自动加载对象，每次握手时检查证书文件的修改时间，发生变化时重新加载，
+// 重新加载失败时继续使用之前的证书
+type certReloader struct {
+	mu       sync.RWMutex     // 并发控制
+	certFile string           // 证书文件
+	keyFile  string           // 私钥文件
+	cert     *tls.Certificate // 当前使用的证书
+	modTime  time.Time        // 当前证书文件的修改时间
+}
+
+// 获取握手使用的证书
+func (c *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	modTime := c.fileModTime()
+	c.mu.RLock()
+	cert, changed := c.cert, !modTime.Equal(c.modTime)
+	c.mu.RUnlock()
+	if !changed {
+		return cert, nil
+	}
+	newCert, err := c.load()
+	if err != nil {
+		// 记录失败时的修改时间，文件再次变化之前不再重新加载
+		c.mu.Lock()
+		c.modTime = modTime
+		c.mu.Unlock()
+		log.Printf("[qhttp] reload certificate '%s' failed: %v", c.certFile, err)
+		return cert, nil
+	}
+	return newCert, nil
+}
+
+// 加载证书文件
+func (c *certReloader) load() (*tls.Certificate, error) {
+	modTime := c.fileModTime()
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cert = &cert
+	c.modTime = modTime
+	return c.cert, nil
+}
+
+// 获取证书以及私钥文件中较新的修改时间
+func (c *certReloader) fileModTime() time.Time {
+	var modTime time.Time
+	for _, file := range []string{c.certFile, c.keyFile} {
+		if info, err := os.Stat(file); err == nil && info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+	}
+	return modTime
+}
+
+// 判断当前请求是否为HTTPS请求
+func (r *Request) IsHTTPS() bool {
+	return r.TLS != nil
+}
+
+// 获取经过验证的客户端证书，客户端没有提供证书或者证书未经过验证时返回nil
+func (r *Request) GetPeerCertificate() *x509.Certificate {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return r.TLS.VerifiedChains[0][0]
+}
+
+// 获取经过验证的客户端证书中的身份标识(CommonName)，没有时返回空
+func (r *Request) GetPeerIdentity() string {
+	if cert := r.GetPeerCertificate(); cert != nil {
+		return cert.Subject.CommonName
+	}
+	return ""
+}
@@ -0,0 +1,180 @@
+package qhttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// 内置的访问日志格式
+const (
+	LOG_FORMAT_COMBINED = "combined" // Apache combined格式，并在末尾追加域名、耗时以及请求ID
+	LOG_FORMAT_JSON     = "json"     // 每行一个JSON对象
+)
+
+// combined格式对应的模板
+const logCombinedTemplate = `{client_ip} - - [{time}] "{method} {uri} {proto}" {status} {bytes} "{referer}" "{user_agent}" {host} {latency} {id}`
+
+// 自定义模板中的变量，例如: {method}
+var logTemplateRegex = regexp.MustCompile(`\{(\w+)\}`)
+
+// 访问日志中的字段名称，JSON格式按照该顺序输出
+var logFields = []string{
+	"time", "id", "client_ip", "remote_ip", "host", "method", "uri", "proto",
+	"status", "bytes", "latency", "referer", "user_agent",
+}
+
+// 设置日志目录，为空时访问日志输出到标准输出，错误日志输出到标准错误
+func (s *Server) SetLogPath(path string) {
+	s.config.LogPath = path
+}
+
+// 设置是否开启访问日志
+func (s *Server) SetAccessLogEnabled(enabled bool) {
+	s.config.AccessLogEnabled = enabled
+}
+
+// 设置是否开启错误日志
+func (s *Server) SetErrorLogEnabled(enabled bool) {
+	s.config.ErrorLogEnabled = enabled
+}
+
+// 设置访问日志格式，可以为combined、json或者包含{method}等变量的自定义模板
+func (s *Server) SetAccessLogFormat(format string) {
+	s.config.AccessLogFormat = format
+}
+
+// 设置日志文件的切分大小(字节)以及保留的备份数量
+func (s *Server) SetLogRotate(size int64, backups int) {
+	s.config.LogRotateSize = size
+	s.config.LogRotateBackups = backups
+}
+
+// 记录访问日志
+func (s *Server) handleAccessLog(r *Request) {
+	if !s.config.AccessLogEnabled {
+		return
+	}
+	fields := r.logFields()
+	var line string
+	switch s.config.AccessLogFormat {
+	case LOG_FORMAT_JSON:
+		line = formatLogJson(fields)
+	case LOG_FORMAT_COMBINED, "":
+		line = formatLogTemplate(logCombinedTemplate, fields)
+	default:
+		line = formatLogTemplate(s.config.AccessLogFormat, fields)
+	}
+	s.logWriter(s.config.AccessLogFile, os.Stdout).Write([]byte(line + "\n"))
+}
+
+// 记录请求处理中产生的错误，<stack>为空时不输出调用栈
+func (s *Server) handleErrorLog(r *Request, err interface{}, stack []byte) {
+	if !s.config.ErrorLogEnabled {
+		return
+	}
+	content := fmt.Sprintf("%s [%d] %s %s %s: %v\n",
+		time.Now().Format("2006-01-02 15:04:05.000"), r.Id, r.Method, r.Host, r.URL.RequestURI(), err)
+	if len(stack) > 0 {
+		content += string(stack)
+	}
+	s.logWriter(s.config.ErrorLogFile, os.Stderr).Write([]byte(content))
+}
+
+// 获取日志文件的写入对象，未设置日志目录时使用<std>
+func (s *Server) logWriter(file string, std io.Writer) io.Writer {
+	if s.config.LogPath == "" {
+		return std
+	}
+	path := filepath.Join(s.config.LogPath, file)
+	v, ok := s.logFiles.Load(path)
+	if !ok {
+		v, _ = s.logFiles.LoadOrStore(path, newLogFile(path))
+	}
+	f := v.(*logFile)
+	f.setRotate(s.config.LogRotateSize, s.config.LogRotateBackups)
+	return f
+}
+
+// 获取访问日志中的字段
+func (r *Request) logFields() map[string]string {
+	return map[string]string{
+		"time":       time.Unix(0, r.EnterTime*1000).Format("02/Jan/2006:15:04:05 -0700"),
+		"id":         strconv.Itoa(r.Id),
+		"client_ip":  r.GetClientIp(),
+		"remote_ip":  r.GetRemoteIp(),
+		"host":       r.Host,
+		"method":     r.Method,
+		"uri":        r.URL.RequestURI(),
+		"proto":      r.Proto,
+		"status":     strconv.Itoa(r.Response.Status),
+		"bytes":      strconv.FormatInt(r.Response.BytesWritten(), 10),
+		"latency":    fmt.Sprintf("%.3fms", float64(r.LeaveTime-r.EnterTime)/1000),
+		"referer":    r.Referer(),
+		"user_agent": r.UserAgent(),
+	}
+}
+
+// 使用模板格式化日志，未知的变量保持原样，变量的值按照nginx的方式转义，避免客户端伪造日志内容
+func formatLogTemplate(template string, fields map[string]string) string {
+	return logTemplateRegex.ReplaceAllStringFunc(template, func(s string) string {
+		if v, ok := fields[s[1:len(s)-1]]; ok {
+			return escapeLogValue(v)
+		}
+		return s
+	})
+}
+
+// 转义日志中的值: 双引号以及反斜杠转义为\"、\\，控制字符以及非ASCII字节转义为\xHH
+func escapeLogValue(value string) string {
+	const hex = "0123456789ABCDEF"
+	var b []byte
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c >= 0x20 && c < 0x7f && c != '"' && c != '\\' {
+			if b != nil {
+				b = append(b, c)
+			}
+			continue
+		}
+		if b == nil {
+			b = append(make([]byte, 0, len(value)+8), value[:i]...)
+		}
+		if c == '"' || c == '\\' {
+			b = append(b, '\\', c)
+		} else {
+			b = append(b, '\\', 'x', hex[c>>4], hex[c&0x0f])
+		}
+	}
+	if b == nil {
+		return value
+	}
+	return string(b)
+}
+
+// 按照固定的字段顺序将日志格式化为JSON
+func formatLogJson(fields map[string]string) string {
+	b := []byte{'{'}
+	for i, k := range logFields {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		key, _ := json.Marshal(k)
+		var value []byte
+		switch k {
+		case "id", "status", "bytes":
+			value = []byte(fields[k])
+		default:
+			value, _ = json.Marshal(fields[k])
+		}
+		b = append(b, key...)
+		b = append(b, ':')
+		b = append(b, value...)
+	}
+	return string(append(b, '}'))
+}
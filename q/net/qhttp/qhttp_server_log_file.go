@@ -0,0 +1,114 @@
+package qhttp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// 支持按照大小切分的日志文件，文件超过大小限制时将当前文件重命名为<file>.1，
+// 已有的备份文件依次后移，超过备份数量的文件被删除
+type logFile struct {
+	mu      sync.Mutex // 并发控制
+	path    string     // 日志文件路径
+	file    *os.File   // 当前打开的文件
+	size    int64      // 当前文件大小
+	maxSize int64      // 文件大小限制，小于等于0时不切分
+	backups int        // 保留的备份文件数量
+}
+
+// 创建日志文件对象，文件在第一次写入时打开
+func newLogFile(path string) *logFile {
+	return &logFile{path: path}
+}
+
+// 设置文件的切分大小以及保留的备份数量，配置变化时关闭当前文件，在下一次写入时重新打开
+func (f *logFile) setRotate(maxSize int64, backups int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.maxSize == maxSize && f.backups == backups {
+		return
+	}
+	f.maxSize = maxSize
+	f.backups = backups
+	if f.file != nil {
+		f.file.Close()
+		f.file = nil
+	}
+}
+
+// 写入日志内容，需要切分时先切分再写入
+func (f *logFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// 关闭日志文件
+func (f *logFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// 打开日志文件，目录不存在时自动创建
+func (f *logFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// 切分日志文件
+func (f *logFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+	if f.backups > 0 {
+		os.Remove(f.backupName(f.backups))
+		for i := f.backups - 1; i > 0; i-- {
+			os.Rename(f.backupName(i), f.backupName(i+1))
+		}
+		if err := os.Rename(f.path, f.backupName(1)); err != nil {
+			return err
+		}
+	} else if err := os.Remove(f.path); err != nil {
+		return err
+	}
+	return f.open()
+}
+
+// 获取备份文件名称
+func (f *logFile) backupName(i int) string {
+	return fmt.Sprintf("%s.%d", f.path, i)
+}
@@ -0,0 +1,144 @@
+package qhttp
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// 对象方法执行之前调用的初始化接口
+type objectInit interface {
+	Init(r *Request)
+}
+
+// 对象方法执行之后调用的结束接口
+type objectShut interface {
+	Shut(r *Request)
+}
+
+// REST对象中方法名称与HTTP方法的映射
+var restMethods = map[string]string{
+	"Get":     "GET",
+	"Post":    "POST",
+	"Put":     "PUT",
+	"Delete":  "DELETE",
+	"Patch":   "PATCH",
+	"Head":    "HEAD",
+	"Options": "OPTIONS",
+	"Connect": "CONNECT",
+	"Trace":   "TRACE",
+}
+
+// 绑定对象，对象中所有签名为func(*Request)的公开方法都会注册为路由，
+// 路由地址为: <pattern>/方法名称(驼峰转换为"-"连接的小写，例如: ShowList => show-list)，
+// <pattern>中包含{method}时使用方法名称替换该变量，Index方法同时绑定到<pattern>本身。
+// 对象实现了Init/Shut方法时，会分别在每个方法执行之前以及之后调用。
+// 可以通过<methods>指定只绑定部分方法。
+func (s *Server) BindObject(pattern string, object interface{}, methods ...string) {
+	s.bindObject(pattern, "", object, nil, methods...)
+}
+
+// 绑定REST对象，对象中的Get/Post/Put/Delete等方法分别注册为对应HTTP方法的路由
+func (s *Server) BindObjectRest(pattern string, object interface{}) {
+	s.bindObjectRest(pattern, "", object, nil)
+}
+
+// 绑定对象的方法
+func (s *Server) bindObject(pattern, domain string, object interface{}, middleware []HandlerFunc, methods ...string) {
+	checkObject(object)
+	allowed := make(map[string]bool, len(methods))
+	for _, v := range methods {
+		allowed[strings.TrimSpace(v)] = true
+	}
+	rv, rt := reflect.ValueOf(object), reflect.TypeOf(object)
+	for i := 0; i < rv.NumMethod(); i++ {
+		name := rt.Method(i).Name
+		if name == "Init" || name == "Shut" || (len(allowed) > 0 && !allowed[name]) {
+			continue
+		}
+		method, ok := rv.Method(i).Interface().(func(*Request))
+		if !ok {
+			continue
+		}
+		handler := objectHandler(object, method)
+		uri := methodUri(name)
+		if strings.Contains(pattern, "{method}") {
+			s.bindObjectHandler(strings.Replace(pattern, "{method}", uri, -1), domain, handler, middleware)
+			continue
+		}
+		s.bindObjectHandler(strings.TrimRight(pattern, "/")+"/"+uri, domain, handler, middleware)
+		if name == "Index" {
+			s.bindObjectHandler(pattern, domain, handler, middleware)
+		}
+	}
+}
+
+// 绑定REST对象的方法
+func (s *Server) bindObjectRest(pattern, domain string, object interface{}, middleware []HandlerFunc) {
+	checkObject(object)
+	rv, rt := reflect.ValueOf(object), reflect.TypeOf(object)
+	for i := 0; i < rv.NumMethod(); i++ {
+		httpMethod, ok := restMethods[rt.Method(i).Name]
+		if !ok {
+			continue
+		}
+		method, ok := rv.Method(i).Interface().(func(*Request))
+		if !ok {
+			continue
+		}
+		s.bindObjectHandler(httpMethod+":"+pattern, domain, objectHandler(object, method), middleware)
+	}
+}
+
+// 注册对象方法对应的路由
+func (s *Server) bindObjectHandler(pattern, domain string, handler HandlerFunc, middleware []HandlerFunc) {
+	if domain != "" {
+		pattern += "@" + domain
+	}
+	if err := s.addItem(pattern, &handlerItem{
+		itemType:   handlerTypeHandler,
+		handler:    handler,
+		middleware: middleware,
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// 生成对象方法的处理方法，在方法执行前后调用对象的Init/Shut方法，
+// 方法中调用Exit/ExitAll或者产生异常时Shut同样会被调用，便于释放Init中获取的资源
+func objectHandler(object interface{}, method func(*Request)) HandlerFunc {
+	initer, _ := object.(objectInit)
+	shuter, _ := object.(objectShut)
+	return func(r *Request) {
+		if shuter != nil {
+			defer shuter.Shut(r)
+		}
+		if initer != nil {
+			initer.Init(r)
+		}
+		method(r)
+	}
+}
+
+// 将方法名称转换为路由地址，例如: ShowList => show-list
+func methodUri(name string) string {
+	var b strings.Builder
+	for i, c := range name {
+		if unicode.IsUpper(c) {
+			if i > 0 {
+				b.WriteByte('-')
+			}
+			c = unicode.ToLower(c)
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// 检查对象是否可以绑定，对象必须是非nil的值
+func checkObject(object interface{}) {
+	if rv := reflect.ValueOf(object); !rv.IsValid() || (rv.Kind() == reflect.Ptr && rv.IsNil()) {
+		panic(errors.New("cannot bind nil object"))
+	}
+}
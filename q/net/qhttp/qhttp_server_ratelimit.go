@@ -0,0 +1,111 @@
+package qhttp
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// 限流算法
+const (
+	RATE_LIMIT_TOKEN_BUCKET   = "token-bucket"   // 令牌桶，允许短时间内的突发请求
+	RATE_LIMIT_SLIDING_WINDOW = "sliding-window" // 滑动窗口，限制任意一个周期内的请求数量
+)
+
+// 获取限流key的方法，返回空字符串时请求不受限流
+type RateLimitKeyFunc = func(r *Request) string
+
+// 限流配置
+type RateLimitConfig struct {
+	Name      string           // 限流名称，作为存储中key的前缀，多个限流共享存储时需要区分
+	Algorithm string           // 限流算法，默认为RATE_LIMIT_TOKEN_BUCKET
+	Limit     int              // 周期内允许的请求数量，必须大于0
+	Period    time.Duration    // 限流周期，默认为1分钟
+	KeyFunc   RateLimitKeyFunc // 获取限流key的方法，默认按照客户端IP限流
+	Storage   RateLimitStorage // 限流存储，为空时使用中间件自身的内存存储
+}
+
+// 设置分组中注册路由的限流，只对之后在分组中注册的路由生效
+func (g *RouterGroup) RateLimit(config RateLimitConfig) *RouterGroup {
+	return g.Middleware(MiddlewareRateLimit(config))
+}
+
+// 限流中间件，超出限制时返回429以及Retry-After，所有请求都会返回RateLimit-Limit、
+// RateLimit-Remaining以及RateLimit-Reset返回头。存储出错时记录日志并允许请求，Limit小于等于0时会panic。
+func MiddlewareRateLimit(config RateLimitConfig) HandlerFunc {
+	if config.Limit <= 0 {
+		panic("qhttp: rate limit must be greater than 0")
+	}
+	if config.Algorithm == "" {
+		config.Algorithm = RATE_LIMIT_TOKEN_BUCKET
+	}
+	if config.Period <= 0 {
+		config.Period = time.Minute
+	}
+	if config.KeyFunc == nil {
+		config.KeyFunc = RateLimitKeyByIp
+	}
+	if config.Storage == nil {
+		config.Storage = NewRateLimitStorageMemory()
+	}
+	rule := RateLimitRule{
+		Algorithm: config.Algorithm,
+		Limit:     config.Limit,
+		Period:    config.Period,
+	}
+	return func(r *Request) {
+		key := config.KeyFunc(r)
+		if key == "" {
+			r.Middleware.Next()
+			return
+		}
+		result, err := config.Storage.Take(config.Name+":"+key, rule)
+		if err != nil {
+			log.Printf("[qhttp] rate limit '%s' failed: %v", key, err)
+			r.Middleware.Next()
+			return
+		}
+		header := r.Response.Header()
+		header.Set("RateLimit-Limit", strconv.Itoa(config.Limit))
+		header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		if !result.Allowed {
+			header.Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			r.Response.WriteStatus(http.StatusTooManyRequests)
+			return
+		}
+		r.Middleware.Next()
+	}
+}
+
+// 按照客户端IP限流
+func RateLimitKeyByIp(r *Request) string {
+	return r.GetClientIp()
+}
+
+// 按照路由规则限流，同一路由的所有请求共享限制
+func RateLimitKeyByRoute(r *Request) string {
+	if r.Router == nil {
+		return r.URL.Path
+	}
+	return r.Router.Method + ":" + r.Router.Uri + "@" + r.Router.Domain
+}
+
+// 按照请求头的值限流，例如API Key，请求头不存在时按照客户端IP限流
+func RateLimitKeyByHeader(name string) RateLimitKeyFunc {
+	return func(r *Request) string {
+		if v := r.Header.Get(name); v != "" {
+			return name + "=" + v
+		}
+		return r.GetClientIp()
+	}
+}
+
+// 将时间转换为向上取整的秒数
+func ceilSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int((d + time.Second - 1) / time.Second)
+}
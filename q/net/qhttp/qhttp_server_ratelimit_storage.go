@@ -0,0 +1,165 @@
+package qhttp
+
+import (
+	"hash/fnv"
+	"math"
+	"sync"
+	"time"
+)
+
+// 限流存储，保存每个key的限流状态并完成一次请求的计数，
+// 实现该接口(例如基于Redis脚本)可以在多个服务实例之间共享限流状态，方法需要并发安全。
+type RateLimitStorage interface {
+	// 按照规则<rule>对<key>的一次请求进行计数，返回请求是否允许以及剩余配额
+	Take(key string, rule RateLimitRule) (RateLimitResult, error)
+}
+
+// 限流规则
+type RateLimitRule struct {
+	Algorithm string        // 限流算法，RATE_LIMIT_TOKEN_BUCKET或者RATE_LIMIT_SLIDING_WINDOW
+	Limit     int           // 周期内允许的请求数量，令牌桶算法中同时为桶的容量
+	Period    time.Duration // 限流周期
+}
+
+// 一次请求的限流结果
+type RateLimitResult struct {
+	Allowed    bool          // 请求是否允许
+	Remaining  int           // 剩余的请求配额
+	Reset      time.Duration // 配额完全恢复(令牌桶)或者当前窗口结束(滑动窗口)的剩余时间
+	RetryAfter time.Duration // 请求不允许时需要等待的时间
+}
+
+// 内存限流存储的分片数量
+const rateLimitShardCount = 32
+
+// 内存限流存储，key按照哈希分布到多个分片中，减少并发请求之间的锁竞争
+type RateLimitStorageMemory struct {
+	shards [rateLimitShardCount]*rateLimitShard
+	now    func() time.Time // 获取当前时间的方法
+}
+
+// 内存限流存储的分片
+type rateLimitShard struct {
+	mu        sync.Mutex
+	entries   map[string]*rateLimitEntry
+	nextSweep time.Time // 下一次清理过期数据的时间
+}
+
+// key的限流状态
+type rateLimitEntry struct {
+	tokens  float64   // 令牌桶中剩余的令牌
+	last    time.Time // 令牌桶上一次计算的时间
+	start   time.Time // 滑动窗口当前窗口的开始时间
+	prev    int       // 滑动窗口上一个窗口的请求数量
+	curr    int       // 滑动窗口当前窗口的请求数量
+	expires time.Time // 状态过期时间，过期之后与新的key一致
+}
+
+// 创建内存限流存储，<now>为获取当前时间的方法，默认为time.Now，可以用于测试或者使用自定义的时钟
+func NewRateLimitStorageMemory(now ...func() time.Time) *RateLimitStorageMemory {
+	s := &RateLimitStorageMemory{now: time.Now}
+	if len(now) > 0 && now[0] != nil {
+		s.now = now[0]
+	}
+	for i := range s.shards {
+		s.shards[i] = &rateLimitShard{entries: make(map[string]*rateLimitEntry)}
+	}
+	return s
+}
+
+// 按照规则对key的一次请求进行计数
+func (s *RateLimitStorageMemory) Take(key string, rule RateLimitRule) (RateLimitResult, error) {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	shard := s.shards[h.Sum32()%rateLimitShardCount]
+	now := s.now()
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	shard.sweep(now)
+	entry, ok := shard.entries[key]
+	if !ok || now.After(entry.expires) {
+		entry = &rateLimitEntry{tokens: float64(rule.Limit), last: now, start: now.Truncate(rule.Period)}
+		shard.entries[key] = entry
+	}
+	if rule.Algorithm == RATE_LIMIT_SLIDING_WINDOW {
+		return entry.takeWindow(rule, now), nil
+	}
+	return entry.takeToken(rule, now), nil
+}
+
+// 定时清理分片中过期的限流状态，调用方需要持有锁
+func (s *rateLimitShard) sweep(now time.Time) {
+	if now.Before(s.nextSweep) {
+		return
+	}
+	s.nextSweep = now.Add(time.Minute)
+	for k, v := range s.entries {
+		if now.After(v.expires) {
+			delete(s.entries, k)
+		}
+	}
+}
+
+// 令牌桶算法，令牌按照Limit/Period的速度持续补充，桶的容量为Limit
+func (e *rateLimitEntry) takeToken(rule RateLimitRule, now time.Time) RateLimitResult {
+	rate := float64(rule.Limit) / float64(rule.Period)
+	e.tokens = math.Min(float64(rule.Limit), e.tokens+float64(now.Sub(e.last))*rate)
+	e.last = now
+	result := RateLimitResult{}
+	if e.tokens >= 1 {
+		e.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration(math.Ceil((1 - e.tokens) / rate))
+	}
+	result.Remaining = int(e.tokens)
+	result.Reset = time.Duration(math.Ceil((float64(rule.Limit) - e.tokens) / rate))
+	// 令牌补满之后的状态与新的key一致
+	e.expires = now.Add(result.Reset)
+	return result
+}
+
+// 滑动窗口算法，使用上一个窗口的请求数量按照时间比例加上当前窗口的请求数量估算最近一个周期内的请求数量
+func (e *rateLimitEntry) takeWindow(rule RateLimitRule, now time.Time) RateLimitResult {
+	period := rule.Period
+	if elapsed := now.Sub(e.start); elapsed >= period {
+		if elapsed < 2*period {
+			e.prev = e.curr
+		} else {
+			e.prev = 0
+		}
+		e.curr = 0
+		e.start = now.Truncate(period)
+	}
+	weight := 1 - float64(now.Sub(e.start))/float64(period)
+	count := float64(e.prev)*weight + float64(e.curr)
+	result := RateLimitResult{Reset: e.start.Add(period).Sub(now)}
+	if count+1 <= float64(rule.Limit) {
+		e.curr++
+		count++
+		result.Allowed = true
+	} else {
+		result.RetryAfter = e.retryAfter(rule, now)
+	}
+	result.Remaining = int(math.Max(0, math.Floor(float64(rule.Limit)-count)))
+	e.expires = e.start.Add(2 * period)
+	return result
+}
+
+// 计算滑动窗口中估算的请求数量降低到允许下一次请求的等待时间
+func (e *rateLimitEntry) retryAfter(rule RateLimitRule, now time.Time) time.Duration {
+	period := float64(rule.Period)
+	limit := float64(rule.Limit - 1)
+	// 当前窗口内上一个窗口的权重降低即可满足
+	if e.prev > 0 && limit-float64(e.curr) >= 0 {
+		offset := period * (1 - (limit-float64(e.curr))/float64(e.prev))
+		return e.start.Add(time.Duration(math.Ceil(offset))).Sub(now)
+	}
+	// 需要等到下一个窗口，当前窗口的请求数量成为上一个窗口的请求数量
+	offset := 0.0
+	if e.curr > 0 {
+		offset = period * math.Max(0, 1-limit/float64(e.curr))
+	}
+	return e.start.Add(rule.Period + time.Duration(math.Ceil(offset))).Sub(now)
+}
@@ -0,0 +1,429 @@
+package qhttp
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// 路由节点类型，同一层级下按照该顺序决定匹配优先级
+const (
+	routerNodeStatic = iota // 静态节点，例如: /user
+	routerNodeRegex         // 正则节点，例如: /{id:\d+} 或 /{page}.html
+	routerNodeParam         // 命名节点，例如: /:id 或 /{id}
+	routerNodeFuzzy         // 模糊节点，例如: /*path，匹配剩余的所有层级
+)
+
+// 路由对象
+type Router struct {
+	Uri     string          // 注册的路由规则(不含HTTP方法以及域名)
+	Method  string          // 绑定的HTTP方法，多个使用","分隔，为空表示所有方法
+	Domain  string          // 绑定的域名，为空表示所有域名
+	methods map[string]bool // 解析后的HTTP方法
+}
+
+// 处理项类型
+const (
+	handlerTypeHandler    = iota // 路由处理方法
+	handlerTypeMiddleware        // 按照路由规则绑定的中间件
+	handlerTypeHook              // 按照路由规则绑定的HOOK方法
+)
+
+// 路由绑定的处理项
+type handlerItem struct {
+	itemType   int           // 处理项类型
+	router     *Router       // 路由对象
+	handler    HandlerFunc   // 处理方法
+	middleware []HandlerFunc // 处理方法绑定的中间件(分组中间件)
+	hookName   string        // HOOK点名称
+	order      int           // 注册顺序
+}
+
+// 路由树节点
+type routerNode struct {
+	segment string                 // 节点对应的路由层级规则
+	kind    int                    // 节点类型
+	name    string                 // 命名节点以及模糊节点的参数名称
+	regex   *regexp.Regexp         // 正则节点的匹配规则
+	names   []string               // 正则节点中的参数名称
+	indexes []int                  // 正则节点中参数对应的子匹配索引
+	static  map[string]*routerNode // 静态子节点
+	dynamic []*routerNode          // 动态子节点(按照优先级排序)
+	items   []*handlerItem         // 当前节点上绑定的处理项
+}
+
+// 路由匹配过程中解析出来的参数
+type routerVar struct {
+	name  string
+	value string
+}
+
+// 路由参数规则，例如: {id} 或 {id:\d+}
+var routerParamRegex = regexp.MustCompile(`^[A-Za-z_][\w\-]*$`)
+
+// 创建路由树的根节点
+func newRouterNode() *routerNode {
+	return &routerNode{
+		static: make(map[string]*routerNode),
+	}
+}
+
+// 解析路由注册规则，格式为: [方法[,方法]:]URI[@域名]，例如: GET:/user/:id@a.com
+func parsePattern(pattern string) (*Router, error) {
+	router := &Router{
+		Uri:     strings.TrimSpace(pattern),
+		methods: make(map[string]bool),
+	}
+	if index := strings.LastIndex(router.Uri, "@"); index != -1 {
+		router.Domain = strings.ToLower(strings.TrimSpace(router.Uri[index+1:]))
+		router.Uri = strings.TrimSpace(router.Uri[:index])
+	}
+	if !strings.HasPrefix(router.Uri, "/") {
+		if index := strings.Index(router.Uri, ":"); index > 0 {
+			router.Method = strings.ToUpper(router.Uri[:index])
+			router.Uri = strings.TrimSpace(router.Uri[index+1:])
+		}
+	}
+	if !strings.HasPrefix(router.Uri, "/") {
+		return nil, fmt.Errorf(`invalid router pattern "%s"`, pattern)
+	}
+	methods := make([]string, 0)
+	for _, v := range strings.Split(router.Method, ",") {
+		if v = strings.TrimSpace(v); v != "" && v != "ALL" {
+			router.methods[v] = true
+			methods = append(methods, v)
+		}
+	}
+	router.Method = strings.Join(methods, ",")
+	return router, nil
+}
+
+// 判断路由是否允许指定的HTTP方法
+func (r *Router) allowMethod(method string) bool {
+	return len(r.methods) == 0 || r.methods[method]
+}
+
+// 将URI拆分为路由层级，首尾的"/"会被忽略
+func splitUri(uri string) []string {
+	uri = strings.Trim(uri, "/")
+	if uri == "" {
+		return []string{}
+	}
+	return strings.Split(uri, "/")
+}
+
+// 根据层级规则创建路由节点
+func newSegmentNode(segment string) (*routerNode, error) {
+	node := newRouterNode()
+	node.segment = segment
+	switch {
+	case strings.HasPrefix(segment, "*"):
+		node.kind = routerNodeFuzzy
+		node.name = segment[1:]
+
+	case strings.HasPrefix(segment, ":"):
+		node.kind = routerNodeParam
+		node.name = segment[1:]
+		if !routerParamRegex.MatchString(node.name) {
+			return nil, fmt.Errorf(`invalid router parameter name in "%s"`, segment)
+		}
+
+	case strings.Contains(segment, "{"):
+		expr, names, err := parseSegmentRegex(segment)
+		if err != nil {
+			return nil, err
+		}
+		// 整个层级只有一个不带规则的参数时，作为命名节点处理
+		if len(names) == 1 && segment == "{"+names[0]+"}" {
+			node.kind = routerNodeParam
+			node.name = names[0]
+			break
+		}
+		regex, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf(`invalid router regex in "%s": %v`, segment, err)
+		}
+		node.kind = routerNodeRegex
+		node.regex = regex
+		node.names = names
+		node.indexes = make([]int, len(names))
+		for i := range names {
+			node.indexes[i] = regex.SubexpIndex(fmt.Sprintf("p%d", i))
+		}
+
+	default:
+		node.kind = routerNodeStatic
+	}
+	return node, nil
+}
+
+// 将带有{name}或{name:regex}的层级规则转换为正则表达式
+func parseSegmentRegex(segment string) (string, []string, error) {
+	var (
+		expr  = "^"
+		names = make([]string, 0)
+		start = 0
+	)
+	for i := 0; i < len(segment); i++ {
+		if segment[i] != '{' {
+			continue
+		}
+		// 查找与之匹配的"}"，正则中可能包含成对的"{}"
+		depth, end := 0, -1
+		for j := i; j < len(segment); j++ {
+			if segment[j] == '{' {
+				depth++
+			} else if segment[j] == '}' {
+				depth--
+				if depth == 0 {
+					end = j
+					break
+				}
+			}
+		}
+		if end == -1 {
+			return "", nil, fmt.Errorf(`unclosed "{" in router segment "%s"`, segment)
+		}
+		name, rule := segment[i+1:end], "[^/]+"
+		if index := strings.Index(name, ":"); index != -1 {
+			name, rule = name[:index], name[index+1:]
+		}
+		if !routerParamRegex.MatchString(name) {
+			return "", nil, fmt.Errorf(`invalid router parameter name in "%s"`, segment)
+		}
+		// 使用内部的分组名称，避免规则中自带的分组影响参数的索引
+		expr += regexp.QuoteMeta(segment[start:i]) + fmt.Sprintf("(?P<p%d>%s)", len(names), rule)
+		names = append(names, name)
+		i, start = end, end+1
+	}
+	expr += regexp.QuoteMeta(segment[start:]) + "$"
+	return expr, names, nil
+}
+
+// 在路由树中添加一条路由
+func (n *routerNode) add(router *Router, item *handlerItem) error {
+	node := n
+	segments := splitUri(router.Uri)
+	for i, segment := range segments {
+		if child, ok := node.static[segment]; ok {
+			node = child
+			continue
+		}
+		var child *routerNode
+		for _, v := range node.dynamic {
+			if v.segment == segment {
+				child = v
+				break
+			}
+		}
+		if child == nil {
+			created, err := newSegmentNode(segment)
+			if err != nil {
+				return err
+			}
+			if created.kind == routerNodeFuzzy && i != len(segments)-1 {
+				return fmt.Errorf(`fuzzy segment "%s" must be the last one in "%s"`, segment, router.Uri)
+			}
+			if created.kind == routerNodeStatic {
+				node.static[segment] = created
+			} else {
+				node.insertDynamic(created)
+			}
+			child = created
+		}
+		node = child
+	}
+	// 相同规则以及相同方法的处理方法将会被覆盖，中间件则按照注册顺序追加
+	if item.itemType == handlerTypeHandler {
+		for i, v := range node.items {
+			if v.itemType == handlerTypeHandler && v.router.Method == router.Method {
+				node.items[i] = item
+				return nil
+			}
+		}
+	}
+	node.items = append(node.items, item)
+	return nil
+}
+
+// 按照优先级插入动态子节点，同类型的节点保持注册顺序
+func (n *routerNode) insertDynamic(child *routerNode) {
+	index := len(n.dynamic)
+	for i, v := range n.dynamic {
+		if v.kind > child.kind {
+			index = i
+			break
+		}
+	}
+	n.dynamic = append(n.dynamic, nil)
+	copy(n.dynamic[index+1:], n.dynamic[index:])
+	n.dynamic[index] = child
+}
+
+// 按照优先级深度优先查找匹配的节点，visit返回true时停止查找
+func (n *routerNode) search(segments []string, vars []routerVar, visit func(node *routerNode, vars []routerVar) bool) bool {
+	if len(segments) == 0 {
+		if len(n.items) > 0 && visit(n, vars) {
+			return true
+		}
+		// 模糊节点允许匹配空的剩余层级
+		for _, child := range n.dynamic {
+			if child.kind == routerNodeFuzzy && len(child.items) > 0 {
+				if visit(child, child.fuzzyVars(vars, "")) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	segment := segments[0]
+	if child, ok := n.static[segment]; ok {
+		if child.search(segments[1:], vars, visit) {
+			return true
+		}
+	}
+	for _, child := range n.dynamic {
+		switch child.kind {
+		case routerNodeRegex:
+			match := child.regex.FindStringSubmatch(segment)
+			if match == nil {
+				continue
+			}
+			matched := vars
+			for i, name := range child.names {
+				matched = append(matched, routerVar{name, match[child.indexes[i]]})
+			}
+			if child.search(segments[1:], matched, visit) {
+				return true
+			}
+
+		case routerNodeParam:
+			if segment == "" {
+				continue
+			}
+			if child.search(segments[1:], append(vars, routerVar{child.name, segment}), visit) {
+				return true
+			}
+
+		case routerNodeFuzzy:
+			if len(child.items) > 0 {
+				if visit(child, child.fuzzyVars(vars, strings.Join(segments, "/"))) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// 模糊节点匹配的参数，未命名的模糊节点不产生参数
+func (n *routerNode) fuzzyVars(vars []routerVar, value string) []routerVar {
+	if n.name == "" {
+		return vars
+	}
+	return append(vars, routerVar{n.name, value})
+}
+
+// 将路由参数转换为请求对象使用的格式
+func buildRouterVars(vars []routerVar) map[string][]string {
+	m := make(map[string][]string, len(vars))
+	for _, v := range vars {
+		m[v.name] = append(m[v.name], v.value)
+	}
+	return m
+}
+
+// 绑定路由规则与处理方法，规则格式为: [方法[,方法]:]URI[@域名]，例如:
+// /user/:id、/files/*path、/list/{page}.html、GET,POST:/order/{id:\d+}、/@a.com
+func (s *Server) BindHandler(pattern string, handler HandlerFunc) {
+	if err := s.addItem(pattern, &handlerItem{
+		itemType: handlerTypeHandler,
+		handler:  handler,
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// 将处理项按照路由规则添加到路由树
+func (s *Server) addItem(pattern string, item *handlerItem) error {
+	router, err := parsePattern(pattern)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.itemOrder++
+	item.router = router
+	item.order = s.itemOrder
+	domain := router.Domain
+	if domain == "" {
+		domain = DEFAULT_DOMAIN
+	}
+	tree, ok := s.routeTrees[domain]
+	if !ok {
+		tree = newRouterNode()
+		s.routeTrees[domain] = tree
+	}
+	return tree.add(router, item)
+}
+
+// 获取请求需要查找的路由树，绑定域名的路由树优先
+func (s *Server) searchTrees(host string) []*routerNode {
+	trees := make([]*routerNode, 0, 2)
+	if tree, ok := s.routeTrees[host]; ok && host != DEFAULT_DOMAIN {
+		trees = append(trees, tree)
+	}
+	if tree, ok := s.routeTrees[DEFAULT_DOMAIN]; ok {
+		trees = append(trees, tree)
+	}
+	return trees
+}
+
+// 根据请求方法、URI以及域名查找处理方法，allowed返回路由匹配但方法不匹配时允许的方法
+func (s *Server) searchHandler(method, uri, host string) (item *handlerItem, vars map[string][]string, allowed []string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	segments := splitUri(uri)
+	for _, tree := range s.searchTrees(host) {
+		found := tree.search(segments, nil, func(node *routerNode, matched []routerVar) bool {
+			for _, v := range node.items {
+				if v.itemType != handlerTypeHandler {
+					continue
+				}
+				if v.router.allowMethod(method) {
+					item, vars = v, buildRouterVars(matched)
+					return true
+				}
+				allowed = append(allowed, v.router.Method)
+			}
+			return false
+		})
+		if found {
+			return
+		}
+	}
+	return
+}
+
+// 查找所有与请求方法、URI以及域名匹配的指定类型处理项，按照注册顺序返回
+func (s *Server) searchItems(itemType int, method, uri, host string) []*handlerItem {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	items := make([]*handlerItem, 0)
+	segments := splitUri(uri)
+	for _, tree := range s.searchTrees(host) {
+		tree.search(segments, nil, func(node *routerNode, matched []routerVar) bool {
+			for _, v := range node.items {
+				if v.itemType == itemType && v.router.allowMethod(method) {
+					items = append(items, v)
+				}
+			}
+			return false
+		})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].order < items[j].order
+	})
+	return items
+}
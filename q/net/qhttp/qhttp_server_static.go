@@ -0,0 +1,180 @@
+package qhttp
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// 设置静态文件服务的根目录，目录不存在时会panic。
+// 静态文件直接输出，不经过中间件(例如鉴权、限流)，只执行HOOK方法，
+// 需要保护的文件可以在BeforeServe HOOK中处理，或者不放在静态目录中
+func (s *Server) SetServerRoot(root string) {
+	s.config.ServerRoot = mustStaticDir(root)
+}
+
+// 添加静态文件的检索目录，在根目录之后按照添加顺序检索，目录不存在时会panic
+func (s *Server) AddSearchPath(dir string) {
+	s.config.SearchPaths = append(s.config.SearchPaths, mustStaticDir(dir))
+}
+
+// 添加静态目录映射，<prefix>开头的请求只在<dir>中检索文件，目录不存在时会panic
+func (s *Server) AddStaticPath(prefix, dir string) {
+	item := StaticPath{
+		Prefix: "/" + strings.Trim(prefix, "/"),
+		Path:   mustStaticDir(dir),
+	}
+	paths := make([]StaticPath, 0, len(s.config.StaticPaths)+1)
+	for _, v := range s.config.StaticPaths {
+		if v.Prefix != item.Prefix {
+			paths = append(paths, v)
+		}
+	}
+	paths = append(paths, item)
+	// 前缀越长越优先匹配
+	sort.SliceStable(paths, func(i, j int) bool {
+		return len(paths[i].Prefix) > len(paths[j].Prefix)
+	})
+	s.config.StaticPaths = paths
+}
+
+// 设置访问目录时默认输出的文件
+func (s *Server) SetIndexFiles(files ...string) {
+	s.config.IndexFiles = files
+}
+
+// 设置目录中没有默认文件时是否列出目录内容
+func (s *Server) SetIndexFolder(enabled bool) {
+	s.config.IndexFolder = enabled
+}
+
+// 设置路由是否优先于静态文件
+func (s *Server) SetRouteOverStatic(enabled bool) {
+	s.config.RouteOverStatic = enabled
+}
+
+// 设置是否允许访问以"."开头的隐藏文件以及目录，例如.git、.env
+func (s *Server) SetStaticHidden(enabled bool) {
+	s.config.StaticHidden = enabled
+}
+
+// 获取目录的绝对路径，目录不存在时panic
+func mustStaticDir(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		panic(err)
+	}
+	if info, err := os.Stat(abs); err != nil || !info.IsDir() {
+		panic(fmt.Errorf("static directory does not exist: %s", dir))
+	}
+	return abs
+}
+
+// 检索请求对应的静态文件，静态目录映射优先于根目录以及检索目录，
+// 返回文件的本地路径，未找到或者不允许访问隐藏文件时返回空
+func (s *Server) searchStaticFile(uri string) string {
+	uri = path.Clean("/" + uri)
+	if !s.config.StaticHidden && isHiddenPath(uri) {
+		return ""
+	}
+	for _, v := range s.config.StaticPaths {
+		if v.Prefix == "/" || uri == v.Prefix || strings.HasPrefix(uri, v.Prefix+"/") {
+			return s.lookupStaticFile(v.Path, strings.TrimPrefix(uri, v.Prefix))
+		}
+	}
+	dirs := s.config.SearchPaths
+	if s.config.ServerRoot != "" {
+		dirs = append([]string{s.config.ServerRoot}, dirs...)
+	}
+	for _, dir := range dirs {
+		if file := s.lookupStaticFile(dir, uri); file != "" {
+			return file
+		}
+	}
+	return ""
+}
+
+// 路径中是否包含以"."开头的文件或者目录
+func isHiddenPath(uri string) bool {
+	return strings.Contains(uri, "/.")
+}
+
+// 在目录中查找文件，目录请求返回默认文件，开启目录列表时返回目录本身
+func (s *Server) lookupStaticFile(dir, uri string) string {
+	file := filepath.Join(dir, filepath.FromSlash(uri))
+	info, err := os.Stat(file)
+	if err != nil {
+		return ""
+	}
+	if !info.IsDir() {
+		return file
+	}
+	for _, v := range s.config.IndexFiles {
+		index := filepath.Join(file, v)
+		if info, err := os.Stat(index); err == nil && !info.IsDir() {
+			return index
+		}
+	}
+	if s.config.IndexFolder {
+		return file
+	}
+	return ""
+}
+
+// 输出静态文件或者目录列表
+func (s *Server) serveStaticFile(r *Request, file string) {
+	r.isFileRequest = true
+	info, err := os.Stat(file)
+	if err != nil {
+		r.Response.WriteStatus(http.StatusNotFound)
+		return
+	}
+	if info.IsDir() {
+		r.Response.listDir(file)
+		return
+	}
+	r.Response.ServeFile(file)
+}
+
+// 输出目录列表
+func (r *Response) listDir(dir string) {
+	file, err := os.Open(dir)
+	if err != nil {
+		r.WriteStatus(http.StatusForbidden)
+		return
+	}
+	defer file.Close()
+	infos, err := file.Readdir(-1)
+	if err != nil {
+		r.WriteStatus(http.StatusInternalServerError)
+		return
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name() < infos[j].Name()
+	})
+	base := strings.TrimRight(r.request.URL.Path, "/") + "/"
+	r.Header().Set("Content-Type", "text/html; charset=utf-8")
+	r.Writef("<html><head><title>%s</title></head><body>\n", html.EscapeString(base))
+	r.Writef("<h1>%s</h1>\n<pre>\n", html.EscapeString(base))
+	if base != "/" {
+		r.Writef("<a href=\"%s\">../</a>\n", html.EscapeString(path.Dir(strings.TrimRight(base, "/"))))
+	}
+	for _, v := range infos {
+		name := v.Name()
+		if !r.Server.config.StaticHidden && strings.HasPrefix(name, ".") {
+			continue
+		}
+		if v.IsDir() {
+			name += "/"
+		}
+		link := (&url.URL{Path: base + name}).String()
+		r.Writef("<a href=\"%s\">%s</a>\n", html.EscapeString(link), html.EscapeString(name))
+	}
+	r.Write("</pre>\n</body></html>")
+}
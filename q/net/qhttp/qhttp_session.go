@@ -0,0 +1,244 @@
+package qhttp
+
+import (
+	"log"
+	"net/http"
+	"sync"
+
+	"grt/q/utils/conv"
+	"grt/q/utils/random"
+)
+
+// 会话ID的长度
+const sessionIdLength = 32
+
+// 会话对象，与请求绑定，在第一次访问时才从存储中加载数据，
+// 数据被修改过时在请求结束时写回存储，否则只刷新存储中的有效期，方法都是并发安全的
+type Session struct {
+	mu      sync.Mutex             // 并发控制
+	id      string                 // 会话ID，为空表示还未创建
+	data    map[string]interface{} // 会话数据
+	loaded  bool                   // 是否已经加载
+	dirty   bool                   // 数据是否被修改过
+	request *Request               // 关联的请求对象
+}
+
+// 创建一个会话对象
+func newSession(r *Request) *Session {
+	return &Session{
+		request: r,
+	}
+}
+
+// 加载会话数据，请求中没有会话ID或者会话已经失效时使用空的数据，调用方需要持有锁
+func (s *Session) init() {
+	if s.loaded {
+		return
+	}
+	s.loaded = true
+	s.data = make(map[string]interface{})
+	id := s.request.getSessionId()
+	if id == "" {
+		return
+	}
+	data, err := s.request.Server.sessionStorage().Get(id, s.request.Server.config.SessionMaxAge)
+	if err != nil {
+		log.Printf("[qhttp] load session '%s' failed: %v", id, err)
+		return
+	}
+	// 不接受存储中不存在的会话ID，避免会话固定攻击
+	if data != nil {
+		s.id = id
+		s.data = data
+	}
+}
+
+// 获取会话ID，会话还未创建时生成新的会话ID
+func (s *Session) Id() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.init()
+	if s.id == "" {
+		s.create()
+	}
+	return s.id
+}
+
+// 获取会话数据的值，不存在时返回<def>
+func (s *Session) Get(key string, def ...interface{}) interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.init()
+	if v, ok := s.data[key]; ok {
+		return v
+	}
+	return defaultValue(def)
+}
+
+// 获取会话数据的字符串值
+func (s *Session) GetString(key string, def ...string) string {
+	if v := s.Get(key); v != nil {
+		return conv.String(v)
+	}
+	return defaultString(def)
+}
+
+// 获取会话数据的整型值
+func (s *Session) GetInt(key string, def ...int) int {
+	if v := s.Get(key); v != nil {
+		return conv.Int(v)
+	}
+	return defaultInt(def)
+}
+
+// 判断会话数据是否存在
+func (s *Session) Contains(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.init()
+	_, ok := s.data[key]
+	return ok
+}
+
+// 获取所有会话数据的副本
+func (s *Session) Map() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.init()
+	return copyMap(s.data)
+}
+
+// 设置会话数据，会话还未创建时会生成新的会话ID
+func (s *Session) Set(key string, value interface{}) {
+	s.Sets(map[string]interface{}{key: value})
+}
+
+// 批量设置会话数据
+func (s *Session) Sets(data map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.init()
+	if s.id == "" {
+		s.create()
+	}
+	for k, v := range data {
+		s.data[k] = v
+	}
+	s.dirty = true
+}
+
+// 删除会话数据
+func (s *Session) Remove(keys ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.init()
+	for _, k := range keys {
+		if _, ok := s.data[k]; ok {
+			delete(s.data, k)
+			s.dirty = true
+		}
+	}
+}
+
+// 清空会话数据，会话ID保持不变
+func (s *Session) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.init()
+	if len(s.data) > 0 {
+		s.data = make(map[string]interface{})
+		s.dirty = true
+	}
+}
+
+// 重新生成会话ID并保留会话数据，用于登录等权限变化的场景，返回新的会话ID
+func (s *Session) RegenerateId() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.init()
+	if s.id != "" {
+		s.removeStorage(s.id)
+	}
+	// 会话ID变化时CSRF令牌同时失效
+	delete(s.data, csrfSessionKey)
+	s.create()
+	s.dirty = true
+	return s.id
+}
+
+// 销毁会话，删除存储中的数据以及客户端的会话Cookie
+func (s *Session) Destroy() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.init()
+	if s.id != "" {
+		s.removeStorage(s.id)
+		s.request.Cookie.Remove(s.request.Server.config.SessionIdName)
+	}
+	s.id = ""
+	s.data = make(map[string]interface{})
+	s.dirty = false
+}
+
+// 生成新的会话ID，并通过HttpOnly的Cookie发送给客户端，调用方需要持有锁
+func (s *Session) create() {
+	s.id = random.SecureStr(sessionIdLength)
+	s.request.Cookie.SetCookie(s.request.Server.config.SessionIdName, s.id, s.request.Cookie.defaultDomain(),
+		s.request.Server.config.CookiePath, 0, true, s.request.IsHTTPS(), http.SameSiteLaxMode)
+}
+
+// 删除存储中的会话数据，调用方需要持有锁
+func (s *Session) removeStorage(id string) {
+	if err := s.request.Server.sessionStorage().Remove(id); err != nil {
+		log.Printf("[qhttp] remove session '%s' failed: %v", id, err)
+	}
+}
+
+// 请求结束时保存会话，数据被修改过时写回存储，否则刷新有效期
+func (s *Session) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.loaded || s.id == "" {
+		return
+	}
+	var (
+		storage = s.request.Server.sessionStorage()
+		ttl     = s.request.Server.config.SessionMaxAge
+		err     error
+	)
+	if s.dirty {
+		err = storage.Set(s.id, s.data, ttl)
+		s.dirty = false
+	} else {
+		err = storage.Touch(s.id, ttl)
+	}
+	if err != nil {
+		log.Printf("[qhttp] save session '%s' failed: %v", s.id, err)
+	}
+}
+
+// 获取请求中的会话ID，依次从Cookie以及请求头中获取，格式不正确的ID会被忽略
+func (r *Request) getSessionId() string {
+	name := r.Server.config.SessionIdName
+	id := r.Cookie.Get(name)
+	if id == "" {
+		id = r.Header.Get(name)
+	}
+	if !isValidSessionId(id) {
+		return ""
+	}
+	return id
+}
+
+// 判断会话ID的格式是否正确，只允许字母以及数字
+func isValidSessionId(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,95 @@
+package qhttp
+
+import (
+	"sync"
+	"time"
+)
+
+// 会话存储接口，实现需要是并发安全的
+type SessionStorage interface {
+	// 获取会话数据，会话不存在或者已经过期时返回nil
+	Get(id string, ttl time.Duration) (map[string]interface{}, error)
+	// 保存会话数据，并将有效期设置为<ttl>
+	Set(id string, data map[string]interface{}, ttl time.Duration) error
+	// 刷新会话的有效期
+	Touch(id string, ttl time.Duration) error
+	// 删除会话数据
+	Remove(id string) error
+}
+
+// 基于内存的会话存储，过期的会话在访问以及定期清理时删除
+type SessionStorageMemory struct {
+	mu        sync.Mutex                    // 并发控制
+	items     map[string]*sessionMemoryItem // 会话数据
+	lastClean time.Time                     // 上一次清理过期会话的时间
+}
+
+// 内存存储中的会话数据项
+type sessionMemoryItem struct {
+	data     map[string]interface{} // 会话数据
+	expireAt time.Time              // 过期时间
+}
+
+// 过期会话的清理间隔
+const sessionMemoryCleanInterval = time.Minute
+
+// 创建基于内存的会话存储
+func NewSessionStorageMemory() *SessionStorageMemory {
+	return &SessionStorageMemory{
+		items:     make(map[string]*sessionMemoryItem),
+		lastClean: time.Now(),
+	}
+}
+
+// 获取会话数据
+func (m *SessionStorageMemory) Get(id string, ttl time.Duration) (map[string]interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, ok := m.items[id]
+	if !ok {
+		return nil, nil
+	}
+	if time.Now().After(item.expireAt) {
+		delete(m.items, id)
+		return nil, nil
+	}
+	return copyMap(item.data), nil
+}
+
+// 保存会话数据
+func (m *SessionStorageMemory) Set(id string, data map[string]interface{}, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	if now.Sub(m.lastClean) > sessionMemoryCleanInterval {
+		for k, v := range m.items {
+			if now.After(v.expireAt) {
+				delete(m.items, k)
+			}
+		}
+		m.lastClean = now
+	}
+	m.items[id] = &sessionMemoryItem{
+		data:     copyMap(data),
+		expireAt: now.Add(ttl),
+	}
+	return nil
+}
+
+// 刷新会话的有效期
+func (m *SessionStorageMemory) Touch(id string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if item, ok := m.items[id]; ok {
+		item.expireAt = time.Now().Add(ttl)
+	}
+	return nil
+}
+
+// 删除会话数据
+func (m *SessionStorageMemory) Remove(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.items, id)
+	return nil
+}
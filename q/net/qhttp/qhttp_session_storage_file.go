@@ -0,0 +1,94 @@
+package qhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// 基于文件的会话存储，每个会话对应目录中的一个JSON文件，使用文件的修改时间判断是否过期
+type SessionStorageFile struct {
+	path string // 存储目录
+}
+
+// 创建基于文件的会话存储，目录不存在时自动创建
+func NewSessionStorageFile(path string) (*SessionStorageFile, error) {
+	if err := os.MkdirAll(path, 0700); err != nil {
+		return nil, err
+	}
+	return &SessionStorageFile{path: path}, nil
+}
+
+// 获取会话数据
+func (f *SessionStorageFile) Get(id string, ttl time.Duration) (map[string]interface{}, error) {
+	file := f.file(id)
+	info, err := os.Stat(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if time.Since(info.ModTime()) > ttl {
+		os.Remove(file)
+		return nil, nil
+	}
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	data := make(map[string]interface{})
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	if err := decoder.Decode(&data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// 保存会话数据，先写入临时文件再重命名，避免读取到写了一半的文件
+func (f *SessionStorageFile) Set(id string, data map[string]interface{}, ttl time.Duration) error {
+	content, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(f.path, id+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(content); err == nil {
+		err = tmp.Close()
+	} else {
+		tmp.Close()
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), f.file(id))
+}
+
+// 刷新会话的有效期
+func (f *SessionStorageFile) Touch(id string, ttl time.Duration) error {
+	now := time.Now()
+	if err := os.Chtimes(f.file(id), now, now); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// 删除会话数据
+func (f *SessionStorageFile) Remove(id string) error {
+	if err := os.Remove(f.file(id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// 获取会话对应的文件路径，会话ID只包含字母以及数字，不会跳出存储目录
+func (f *SessionStorageFile) file(id string) string {
+	return filepath.Join(f.path, filepath.Base(id)+".session")
+}
@@ -0,0 +1,217 @@
+package qhttp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// Redis会话存储的配置
+type SessionRedisConfig struct {
+	Addr     string        // 服务地址，例如: 127.0.0.1:6379
+	Password string        // 密码，为空时不认证
+	Db       int           // 数据库编号
+	Prefix   string        // 会话键名的前缀
+	Timeout  time.Duration // 连接以及读写的超时时间
+	MaxIdle  int           // 最大空闲连接数
+}
+
+// 基于Redis的会话存储，通过RESP协议与兼容Redis的服务通信，
+// 使用GET/SET(PX参数)/PEXPIRE/DEL命令，配置了密码以及数据库时还会使用AUTH/SELECT命令，数据编码为JSON
+type SessionStorageRedis struct {
+	config SessionRedisConfig // 配置
+	idle   chan *redisConn    // 空闲连接池
+}
+
+// Redis连接
+type redisConn struct {
+	conn   net.Conn      // 底层连接
+	reader *bufio.Reader // 读取缓冲
+}
+
+// 创建基于Redis的会话存储，连接在第一次使用时建立
+func NewSessionStorageRedis(config SessionRedisConfig) *SessionStorageRedis {
+	if config.Prefix == "" {
+		config.Prefix = "session:"
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Second
+	}
+	if config.MaxIdle <= 0 {
+		config.MaxIdle = 8
+	}
+	return &SessionStorageRedis{
+		config: config,
+		idle:   make(chan *redisConn, config.MaxIdle),
+	}
+}
+
+// 获取会话数据
+func (r *SessionStorageRedis) Get(id string, ttl time.Duration) (map[string]interface{}, error) {
+	reply, err := r.do("GET", r.config.Prefix+id)
+	if err != nil || reply == nil {
+		return nil, err
+	}
+	content, ok := reply.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("unexpected redis reply: %v", reply)
+	}
+	data := make(map[string]interface{})
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	if err := decoder.Decode(&data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// 保存会话数据
+func (r *SessionStorageRedis) Set(id string, data map[string]interface{}, ttl time.Duration) error {
+	content, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = r.do("SET", r.config.Prefix+id, string(content), "PX", redisTTL(ttl))
+	return err
+}
+
+// 刷新会话的有效期
+func (r *SessionStorageRedis) Touch(id string, ttl time.Duration) error {
+	_, err := r.do("PEXPIRE", r.config.Prefix+id, redisTTL(ttl))
+	return err
+}
+
+// 将有效期转换为毫秒数，Redis不接受小于1毫秒的有效期，此时使用默认的会话有效期
+func redisTTL(ttl time.Duration) string {
+	if ttl < time.Millisecond {
+		ttl = defaultServerConfig.SessionMaxAge
+	}
+	return strconv.FormatInt(int64(ttl/time.Millisecond), 10)
+}
+
+// 删除会话数据
+func (r *SessionStorageRedis) Remove(id string) error {
+	_, err := r.do("DEL", r.config.Prefix+id)
+	return err
+}
+
+// 执行命令，出现网络错误的连接会被丢弃，Redis返回的错误不影响连接的复用
+func (r *SessionStorageRedis) do(args ...string) (interface{}, error) {
+	c, err := r.get()
+	if err != nil {
+		return nil, err
+	}
+	reply, err := c.do(r.config.Timeout, args...)
+	if _, ok := err.(redisError); err != nil && !ok {
+		c.conn.Close()
+		return nil, err
+	}
+	r.put(c)
+	return reply, err
+}
+
+// 从连接池中获取连接，没有空闲连接时建立新的连接
+func (r *SessionStorageRedis) get() (*redisConn, error) {
+	select {
+	case c := <-r.idle:
+		return c, nil
+	default:
+	}
+	conn, err := net.DialTimeout("tcp", r.config.Addr, r.config.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	c := &redisConn{conn: conn, reader: bufio.NewReader(conn)}
+	if r.config.Password != "" {
+		if _, err := c.do(r.config.Timeout, "AUTH", r.config.Password); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if r.config.Db != 0 {
+		if _, err := c.do(r.config.Timeout, "SELECT", strconv.Itoa(r.config.Db)); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// 将连接放回连接池，连接池已满时关闭连接
+func (r *SessionStorageRedis) put(c *redisConn) {
+	select {
+	case r.idle <- c:
+	default:
+		c.conn.Close()
+	}
+}
+
+// Redis服务返回的错误
+type redisError string
+
+func (e redisError) Error() string {
+	return string(e)
+}
+
+// 发送命令并读取返回结果
+func (c *redisConn) do(timeout time.Duration, args ...string) (interface{}, error) {
+	c.conn.SetDeadline(time.Now().Add(timeout))
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, v := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(v), v)
+	}
+	if _, err := c.conn.Write(b.Bytes()); err != nil {
+		return nil, err
+	}
+	return c.readReply()
+}
+
+// 读取RESP格式的返回结果，字符串返回[]byte，整数返回int64，空值返回nil
+func (c *redisConn) readReply() (interface{}, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, errors.New("invalid redis reply")
+	}
+	line = line[:len(line)-2]
+	switch line[0] {
+	case '+':
+		return []byte(line[1:]), nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(c.reader, data); err != nil {
+			return nil, err
+		}
+		return data[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = c.readReply(); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("invalid redis reply: %s", line)
+}
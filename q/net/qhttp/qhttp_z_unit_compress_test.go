@@ -0,0 +1,104 @@
+package qhttp_test
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"gf/g/test/gtest"
+	"grt/q/net/qhttp"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestCompress(t *testing.T) {
+	content := strings.Repeat("hello world ", 200)
+	s := newServer("compress")
+	s.SetCompress()
+	s.BindHandler("/text", func(r *qhttp.Request) {
+		r.Response.Write(content)
+	})
+	s.BindHandler("/small", func(r *qhttp.Request) {
+		r.Response.Write("hello")
+	})
+	s.BindHandler("/image", func(r *qhttp.Request) {
+		r.Response.Header().Set("Content-Type", "image/png")
+		r.Response.Write(content)
+	})
+	s.BindHandler("/encoded", func(r *qhttp.Request) {
+		r.Response.Header().Set("Content-Encoding", "br")
+		r.Response.Write(content)
+	})
+	s.BindHandler("/sse", func(r *qhttp.Request) {
+		r.Response.SSE().SendData(content)
+	})
+	gtest.Case(t, func() {
+		w := serveRequest(s, "GET", "/text", "", map[string]string{"Accept-Encoding": "deflate;q=0.5, gzip"})
+		gtest.Assert(w.Header().Get("Content-Encoding"), "gzip")
+		gtest.Assert(w.Header().Get("Vary"), "Accept-Encoding")
+		gtest.Assert(strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain"), true)
+		gtest.AssertLT(w.Body.Len(), len(content))
+		reader, _ := gzip.NewReader(w.Body)
+		data, _ := ioutil.ReadAll(reader)
+		gtest.Assert(string(data), content)
+
+		w = serveRequest(s, "GET", "/text", "", map[string]string{"Accept-Encoding": "gzip;q=0, deflate"})
+		gtest.Assert(w.Header().Get("Content-Encoding"), "deflate")
+		reader2, _ := zlib.NewReader(w.Body)
+		data, _ = ioutil.ReadAll(reader2)
+		gtest.Assert(string(data), content)
+
+		// 客户端不支持压缩时仍然需要Vary
+		w = serveRequest(s, "GET", "/text", "", map[string]string{"Accept-Encoding": ""})
+		gtest.Assert(w.Header().Get("Content-Encoding"), "")
+		gtest.Assert(w.Header().Get("Vary"), "Accept-Encoding")
+		gtest.Assert(w.Body.String(), content)
+
+		w = serveRequest(s, "GET", "/small", "", map[string]string{"Accept-Encoding": "gzip"})
+		gtest.Assert(w.Header().Get("Content-Encoding"), "")
+		gtest.Assert(w.Body.String(), "hello")
+
+		w = serveRequest(s, "GET", "/image", "", map[string]string{"Accept-Encoding": "gzip"})
+		gtest.Assert(w.Header().Get("Content-Encoding"), "")
+		gtest.Assert(w.Header().Get("Vary"), "")
+
+		w = serveRequest(s, "GET", "/encoded", "", map[string]string{"Accept-Encoding": "gzip"})
+		gtest.Assert(w.Header().Get("Content-Encoding"), "br")
+		gtest.Assert(w.Body.String(), content)
+
+		w = serveRequest(s, "GET", "/sse", "", map[string]string{"Accept-Encoding": "gzip"})
+		gtest.Assert(w.Header().Get("Content-Encoding"), "")
+		gtest.Assert(w.Body.String(), "data: "+content+"\n\n")
+	})
+}
+
+func TestCompressGroup(t *testing.T) {
+	content := strings.Repeat(`{"name":"john"},`, 10)
+	s := newServer("compress-group")
+	s.Group("/api", func(g *qhttp.RouterGroup) {
+		g.Compress(qhttp.CompressConfig{MinLength: 100, ContentTypes: []string{"application/json"}})
+		g.GET("/list", func(r *qhttp.Request) {
+			r.Response.Header().Set("Content-Type", "application/json")
+			r.Response.Write(content)
+		})
+		g.Group("/raw", func(g *qhttp.RouterGroup) {
+			g.Compress(qhttp.CompressConfig{Disabled: true})
+			g.GET("/list", func(r *qhttp.Request) {
+				r.Response.Header().Set("Content-Type", "application/json")
+				r.Response.Write(content)
+			})
+		})
+	})
+	s.BindHandler("/list", func(r *qhttp.Request) {
+		r.Response.Header().Set("Content-Type", "application/json")
+		r.Response.Write(content)
+	})
+	gtest.Case(t, func() {
+		w := serveRequest(s, "GET", "/api/list", "", map[string]string{"Accept-Encoding": "gzip"})
+		gtest.Assert(w.Header().Get("Content-Encoding"), "gzip")
+		w = serveRequest(s, "GET", "/api/raw/list", "", map[string]string{"Accept-Encoding": "gzip"})
+		gtest.Assert(w.Header().Get("Content-Encoding"), "")
+		gtest.Assert(w.Body.String(), content)
+		w = serveRequest(s, "GET", "/list", "", map[string]string{"Accept-Encoding": "gzip"})
+		gtest.Assert(w.Header().Get("Content-Encoding"), "")
+	})
+}
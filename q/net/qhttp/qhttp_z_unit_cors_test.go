@@ -0,0 +1,124 @@
+package qhttp_test
+
+import (
+	"gf/g/test/gtest"
+	"grt/q/net/qhttp"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCORSDefault(t *testing.T) {
+	s := newServer("cors-default")
+	s.Use(qhttp.MiddlewareCORS())
+	s.BindHandler("POST:/user", func(r *qhttp.Request) {
+		r.Response.Write("created")
+	})
+	gtest.Case(t, func() {
+		w := serveRequest(s, "POST", "/user", "", map[string]string{"Origin": "https://a.com"})
+		gtest.Assert(w.Body.String(), "created")
+		gtest.Assert(w.Header().Get("Access-Control-Allow-Origin"), "*")
+		gtest.Assert(w.Header().Get("Vary"), "Origin")
+
+		// 只注册了POST方法的路由同样可以响应预检请求
+		w = serveRequest(s, "OPTIONS", "/user", "", map[string]string{
+			"Origin":                         "https://a.com",
+			"Access-Control-Request-Method":  "POST",
+			"Access-Control-Request-Headers": "content-type",
+		})
+		gtest.Assert(w.Code, http.StatusNoContent)
+		gtest.Assert(w.Body.String(), "")
+		gtest.Assert(w.Header().Get("Access-Control-Allow-Origin"), "*")
+		gtest.Assert(strings.Contains(w.Header().Get("Access-Control-Allow-Methods"), "POST"), true)
+
+		// 不是预检请求的OPTIONS请求以及同源请求不受影响
+		w = serveRequest(s, "OPTIONS", "/user", "", nil)
+		gtest.Assert(w.Code, http.StatusMethodNotAllowed)
+		w = serveRequest(s, "POST", "/user", "", map[string]string{"Origin": "http://example.com"})
+		gtest.Assert(w.Header().Get("Access-Control-Allow-Origin"), "")
+		w = serveRequest(s, "POST", "/user", "", map[string]string{"Origin": "http://example.com:80"})
+		gtest.Assert(w.Header().Get("Access-Control-Allow-Origin"), "")
+
+		// 只有端口不同时同样是跨域请求
+		w = serveRequest(s, "POST", "/user", "", map[string]string{"Origin": "http://example.com:3000"})
+		gtest.Assert(w.Header().Get("Access-Control-Allow-Origin"), "*")
+		w = serveRequest(s, "OPTIONS", "http://localhost:8080/user", "", map[string]string{
+			"Origin":                        "http://localhost:3000",
+			"Access-Control-Request-Method": "POST",
+		})
+		gtest.Assert(w.Code, http.StatusNoContent)
+		gtest.Assert(w.Header().Get("Access-Control-Allow-Origin"), "*")
+		w = serveRequest(s, "POST", "http://localhost:8080/user", "", map[string]string{"Origin": "http://localhost:3000"})
+		gtest.Assert(w.Header().Get("Access-Control-Allow-Origin"), "*")
+		w = serveRequest(s, "POST", "http://localhost:8080/user", "", map[string]string{"Origin": "http://localhost:8080"})
+		gtest.Assert(w.Header().Get("Access-Control-Allow-Origin"), "")
+	})
+}
+
+func TestCORSConfig(t *testing.T) {
+	s := newServer("cors-config")
+	s.Group("/api", func(g *qhttp.RouterGroup) {
+		g.CORS(qhttp.CORSConfig{
+			AllowOrigins:     []string{"https://a.com", "*.b.com"},
+			AllowOriginFunc:  func(r *qhttp.Request, origin string) bool { return origin == "http://c.com:8080" },
+			AllowMethods:     []string{"GET", "PUT"},
+			AllowHeaders:     []string{"Content-Type", "X-Token"},
+			ExposeHeaders:    []string{"X-Total"},
+			AllowCredentials: true,
+			MaxAge:           time.Hour,
+		})
+		g.GET("/user/:id", func(r *qhttp.Request) {
+			r.Response.Write(r.GetRouterString("id"))
+		})
+		g.PUT("/user/:id", func(r *qhttp.Request) {
+			r.Response.Write("updated")
+		})
+	})
+	s.BindHandler("GET:/other", func(r *qhttp.Request) {
+		r.Response.Write("other")
+	})
+	gtest.Case(t, func() {
+		for _, origin := range []string{"https://a.com", "https://x.b.com", "http://y.z.b.com:81", "http://c.com:8080"} {
+			w := serveRequest(s, "GET", "/api/user/1", "", map[string]string{"Origin": origin})
+			gtest.Assert(w.Body.String(), "1")
+			gtest.Assert(w.Header().Get("Access-Control-Allow-Origin"), origin)
+			gtest.Assert(w.Header().Get("Access-Control-Allow-Credentials"), "true")
+			gtest.Assert(w.Header().Get("Access-Control-Expose-Headers"), "X-Total")
+		}
+		for _, origin := range []string{"http://a.com", "https://b.com", "https://evilb.com", "http://c.com"} {
+			w := serveRequest(s, "GET", "/api/user/1", "", map[string]string{"Origin": origin})
+			gtest.Assert(w.Body.String(), "1")
+			gtest.Assert(w.Header().Get("Access-Control-Allow-Origin"), "")
+		}
+
+		preflight := map[string]string{
+			"Origin":                         "https://x.b.com",
+			"Access-Control-Request-Method":  "PUT",
+			"Access-Control-Request-Headers": "X-Token",
+		}
+		w := serveRequest(s, "OPTIONS", "/api/user/1", "", preflight)
+		gtest.Assert(w.Code, http.StatusNoContent)
+		gtest.Assert(w.Header().Get("Access-Control-Allow-Methods"), "GET, PUT")
+		gtest.Assert(w.Header().Get("Access-Control-Allow-Headers"), "Content-Type, X-Token")
+		gtest.Assert(w.Header().Get("Access-Control-Max-Age"), "3600")
+
+		// 请求头不允许
+		preflight["Access-Control-Request-Method"] = "GET"
+		preflight["Access-Control-Request-Headers"] = "X-Other"
+		w = serveRequest(s, "OPTIONS", "/api/user/1", "", preflight)
+		gtest.Assert(w.Code, http.StatusForbidden)
+		gtest.Assert(w.Header().Get("Access-Control-Allow-Origin"), "")
+
+		preflight["Access-Control-Request-Headers"] = ""
+		preflight["Origin"] = "https://evil.com"
+		w = serveRequest(s, "OPTIONS", "/api/user/1", "", preflight)
+		gtest.Assert(w.Code, http.StatusForbidden)
+
+		// 没有CORS中间件的路由与普通的方法不匹配一致
+		preflight["Origin"] = "https://a.com"
+		w = serveRequest(s, "OPTIONS", "/other", "", preflight)
+		gtest.Assert(w.Code, http.StatusMethodNotAllowed)
+		gtest.Assert(w.Header().Get("Allow"), "GET")
+	})
+}
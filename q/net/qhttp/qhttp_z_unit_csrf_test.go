@@ -0,0 +1,107 @@
+package qhttp_test
+
+import (
+	"gf/g/test/gtest"
+	"grt/q/net/qhttp"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// 携带Cookie以及CSRF令牌请求服务，<form>不为空时以表单方式提交
+func csrfRequest(s *qhttp.Server, method, uri, cookie, token string, form url.Values) *httptest.ResponseRecorder {
+	headers := map[string]string{"Cookie": cookie, "X-CSRF-Token": token}
+	if form != nil {
+		headers["Content-Type"] = "application/x-www-form-urlencoded"
+	}
+	return serveRequest(s, method, uri, form.Encode(), headers)
+}
+
+func TestCSRFSession(t *testing.T) {
+	s := newServer("csrf-session")
+	s.Use(qhttp.MiddlewareCSRF())
+	s.BindHandler("/token", func(r *qhttp.Request) {
+		r.Response.Write(r.CSRFToken())
+	})
+	s.BindHandler("POST:/save", func(r *qhttp.Request) {
+		r.Response.Write("saved")
+	})
+	s.BindHandler("POST:/login", func(r *qhttp.Request) {
+		r.Session.RegenerateId()
+		r.Response.Write(r.CSRFToken())
+	})
+	gtest.Case(t, func() {
+		w := csrfRequest(s, "GET", "/token", "", "", nil)
+		token := w.Body.String()
+		id := sessionCookie(w)
+		gtest.Assert(len(token), 32)
+		cookie := "qsessionid=" + id
+
+		w = csrfRequest(s, "GET", "/token", cookie, "", nil)
+		gtest.Assert(w.Body.String(), token)
+
+		w = csrfRequest(s, "POST", "/save", cookie, "", nil)
+		gtest.Assert(w.Code, http.StatusForbidden)
+		w = csrfRequest(s, "POST", "/save", cookie, "invalid", nil)
+		gtest.Assert(w.Code, http.StatusForbidden)
+		w = csrfRequest(s, "POST", "/save", "", token, nil)
+		gtest.Assert(w.Code, http.StatusForbidden)
+		w = csrfRequest(s, "POST", "/save", cookie, token, nil)
+		gtest.Assert(w.Body.String(), "saved")
+		w = csrfRequest(s, "POST", "/save", cookie, "", url.Values{"_csrf": {token}})
+		gtest.Assert(w.Body.String(), "saved")
+
+		// 登录时会话ID以及令牌同时更新
+		w = csrfRequest(s, "POST", "/login", cookie, token, nil)
+		newToken := w.Body.String()
+		newCookie := "qsessionid=" + sessionCookie(w)
+		gtest.Assert(len(newToken), 32)
+		gtest.AssertNE(newToken, token)
+		w = csrfRequest(s, "POST", "/save", newCookie, token, nil)
+		gtest.Assert(w.Code, http.StatusForbidden)
+		w = csrfRequest(s, "POST", "/save", newCookie, newToken, nil)
+		gtest.Assert(w.Body.String(), "saved")
+	})
+}
+
+func TestCSRFCookie(t *testing.T) {
+	s := newServer("csrf-cookie")
+	s.Group("/", func(g *qhttp.RouterGroup) {
+		g.CSRF(qhttp.CSRFConfig{Storage: qhttp.CSRF_STORAGE_COOKIE, CookieName: "token"})
+		g.GET("/page", func(r *qhttp.Request) {
+			r.Response.Write("page")
+		})
+		g.POST("/save", func(r *qhttp.Request) {
+			r.Response.Write("saved")
+		})
+		g.POST("/login", func(r *qhttp.Request) {
+			r.Response.Write(r.RotateCSRFToken())
+		})
+	})
+	gtest.Case(t, func() {
+		w := csrfRequest(s, "GET", "/page", "", "", nil)
+		cookies := w.Result().Cookies()
+		gtest.Assert(len(cookies), 1)
+		gtest.Assert(cookies[0].Name, "token")
+		gtest.Assert(cookies[0].HttpOnly, false)
+		token := cookies[0].Value
+		gtest.Assert(len(token), 32)
+
+		// 已经存在令牌时不会重新下发
+		w = csrfRequest(s, "GET", "/page", "token="+token, "", nil)
+		gtest.Assert(len(w.Result().Cookies()), 0)
+
+		w = csrfRequest(s, "POST", "/save", "token="+token, "", nil)
+		gtest.Assert(w.Code, http.StatusForbidden)
+		w = csrfRequest(s, "POST", "/save", "", token, nil)
+		gtest.Assert(w.Code, http.StatusForbidden)
+		w = csrfRequest(s, "POST", "/save", "token="+token, token, nil)
+		gtest.Assert(w.Body.String(), "saved")
+
+		w = csrfRequest(s, "POST", "/login", "token="+token, token, nil)
+		newToken := w.Body.String()
+		gtest.AssertNE(newToken, token)
+		gtest.Assert(w.Result().Cookies()[0].Value, newToken)
+	})
+}
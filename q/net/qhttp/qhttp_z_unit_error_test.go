@@ -0,0 +1,76 @@
+package qhttp_test
+
+import (
+	"errors"
+	"gf/g/test/gtest"
+	"grt/q/net/qhttp"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestErrorStatusHandler(t *testing.T) {
+	s := newServer("error-status")
+	s.SetErrorLogEnabled(false)
+	s.BindStatusHandlerByMap(map[int]qhttp.HandlerFunc{
+		http.StatusNotFound: func(r *qhttp.Request) {
+			r.Response.Write("page not found: ", r.URL.Path)
+		},
+		http.StatusInternalServerError: func(r *qhttp.Request) {
+			r.Response.Write("server error")
+		},
+	})
+	s.Domain("a.com").BindStatusHandler(http.StatusNotFound, func(r *qhttp.Request) {
+		r.Response.Write("a.com not found")
+	})
+	s.BindHandler("/panic", func(r *qhttp.Request) {
+		r.Response.Write("partial")
+		panic("something wrong")
+	})
+	s.BindHandler("/missing", func(r *qhttp.Request) {
+		r.Response.WriteStatus(http.StatusNotFound, "ignored")
+	})
+	gtest.Case(t, func() {
+		code, body := request(s, "GET", "/none")
+		gtest.Assert(code, http.StatusNotFound)
+		gtest.Assert(body, "page not found: /none")
+		code, body = request(s, "GET", "/missing")
+		gtest.Assert(code, http.StatusNotFound)
+		gtest.Assert(body, "page not found: /missing")
+		code, body = request(s, "GET", "/panic")
+		gtest.Assert(code, http.StatusInternalServerError)
+		gtest.Assert(body, "server error")
+
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest("GET", "http://a.com/none", nil))
+		gtest.Assert(w.Code, http.StatusNotFound)
+		gtest.Assert(w.Body.String(), "a.com not found")
+	})
+}
+
+func TestErrorHandler(t *testing.T) {
+	s := newServer("error-handler")
+	s.SetErrorLogEnabled(false)
+	s.SetErrorHandler(func(r *qhttp.Request, err error) {
+		if r.GetString("again") != "" {
+			panic("again")
+		}
+		r.Response.WriteStatus(http.StatusServiceUnavailable, "handled: ", err.Error())
+	})
+	s.BindHandler("/panic", func(r *qhttp.Request) {
+		panic("something wrong")
+	})
+	s.BindHandler("/error", func(r *qhttp.Request) {
+		panic(errors.New("error value"))
+	})
+	gtest.Case(t, func() {
+		code, body := request(s, "GET", "/panic")
+		gtest.Assert(code, http.StatusServiceUnavailable)
+		gtest.Assert(body, "handled: something wrong")
+		_, body = request(s, "GET", "/error")
+		gtest.Assert(body, "handled: error value")
+		code, body = request(s, "GET", "/panic?again=1")
+		gtest.Assert(code, http.StatusInternalServerError)
+		gtest.Assert(body, "Internal Server Error")
+	})
+}
@@ -0,0 +1,118 @@
+//go:build !windows
+// +build !windows
+
+package qhttp_test
+
+import (
+	"bufio"
+	"fmt"
+	"gf/g/test/gtest"
+	"grt/q/net/qhttp"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// 平滑重启测试中父子进程执行的服务，输出监听地址之后阻塞运行
+func TestGracefulHelperProcess(t *testing.T) {
+	if os.Getenv("QHTTP_TEST_GRACEFUL") == "" {
+		return
+	}
+	s := qhttp.GetServer("graceful")
+	s.SetAddr("127.0.0.1:0")
+	s.SetGraceful(true)
+	s.SetAccessLogEnabled(false)
+	s.BindHandler("/pid", func(r *qhttp.Request) {
+		r.Response.Write(os.Getpid())
+	})
+	s.BindHandler("/slow", func(r *qhttp.Request) {
+		time.Sleep(300 * time.Millisecond)
+		r.Response.Write("slow:", os.Getpid())
+	})
+	if err := s.Start(); err != nil {
+		fmt.Println("error:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("listening %d %s\n", os.Getpid(), s.ListenedAddrs()[0])
+	qhttp.Wait()
+	fmt.Printf("exited %d\n", os.Getpid())
+	os.Exit(0)
+}
+
+// 获取服务返回的内容
+func httpGet(url string) string {
+	resp, err := http.Get(url)
+	if err != nil {
+		return err.Error()
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	return string(body)
+}
+
+func TestGracefulRestart(t *testing.T) {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestGracefulHelperProcess$")
+	cmd.Env = append(os.Environ(), "QHTTP_TEST_GRACEFUL=1")
+	stdout, _ := cmd.StdoutPipe()
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	lines := make(chan string, 10)
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	readLine := func(prefix string) []string {
+		timeout := time.After(10 * time.Second)
+		for {
+			select {
+			case line := <-lines:
+				if strings.HasPrefix(line, prefix) {
+					return strings.Fields(line)
+				}
+			case <-timeout:
+				t.Fatalf("waiting for %q timeout", prefix)
+			}
+		}
+	}
+	fields := readLine("listening")
+	parentPid, url := fields[1], "http://"+fields[2]
+	childPid := 0
+	defer func() {
+		if childPid > 0 {
+			syscall.Kill(childPid, syscall.SIGKILL)
+		}
+		cmd.Process.Kill()
+	}()
+
+	gtest.Case(t, func() {
+		gtest.Assert(httpGet(url+"/pid"), parentPid)
+		slow := make(chan string, 1)
+		go func() {
+			slow <- httpGet(url + "/slow")
+		}()
+		time.Sleep(100 * time.Millisecond)
+		gtest.Assert(cmd.Process.Signal(syscall.SIGUSR2), nil)
+
+		// 子进程使用继承的监听对象，监听地址保持不变
+		fields = readLine("listening")
+		childPid, _ = strconv.Atoi(fields[1])
+		gtest.AssertNE(fields[1], parentPid)
+		gtest.Assert("http://"+fields[2], url)
+
+		// 父进程处理完正在进行的请求之后退出
+		gtest.Assert(<-slow, "slow:"+parentPid)
+		gtest.Assert(readLine("exited")[1], parentPid)
+		gtest.Assert(cmd.Wait(), nil)
+		gtest.Assert(httpGet(url+"/pid"), fields[1])
+	})
+}
@@ -0,0 +1,89 @@
+package qhttp_test
+
+import (
+	"gf/g/test/gtest"
+	"grt/q/net/qhttp"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestHookOrder(t *testing.T) {
+	s := newServer("hook-order")
+	var closed []string
+	s.BindHookHandlerByMap("/*", map[string]qhttp.HandlerFunc{
+		qhttp.HOOK_BEFORE_SERVE: func(r *qhttp.Request) {
+			r.Response.Write("before-serve>")
+		},
+		qhttp.HOOK_AFTER_SERVE: func(r *qhttp.Request) {
+			r.Response.Write("<after-serve")
+		},
+		qhttp.HOOK_BEFORE_OUTPUT: func(r *qhttp.Request) {
+			r.Response.SetBuffer([]byte(strings.ToUpper(r.Response.BufferString())))
+		},
+		qhttp.HOOK_BEFORE_CLOSE: func(r *qhttp.Request) {
+			closed = append(closed, r.URL.Path)
+		},
+	})
+	s.BindHookHandler("/user/:id", qhttp.HOOK_BEFORE_SERVE, func(r *qhttp.Request) {
+		r.Response.Write("id:", r.GetRouterString("id"), ">")
+	})
+	s.BindHandler("/user/:id", func(r *qhttp.Request) {
+		r.Response.Write("user")
+	})
+	gtest.Case(t, func() {
+		_, body := request(s, "GET", "/user/1")
+		gtest.Assert(body, "BEFORE-SERVE>ID:1>USER<AFTER-SERVE")
+		code, body := request(s, "GET", "/none")
+		gtest.Assert(code, http.StatusNotFound)
+		gtest.Assert(body, "BEFORE-SERVE><AFTER-SERVE")
+		gtest.Assert(closed, []string{"/user/1", "/none"})
+	})
+}
+
+func TestHookExit(t *testing.T) {
+	s := newServer("hook-exit")
+	s.BindHookHandler("/*", qhttp.HOOK_BEFORE_SERVE, func(r *qhttp.Request) {
+		r.Response.Write("h1>")
+		if r.GetString("skip") != "" {
+			r.ExitHook()
+		}
+	})
+	s.BindHookHandler("/*", qhttp.HOOK_BEFORE_SERVE, func(r *qhttp.Request) {
+		r.Response.Write("h2>")
+		if r.GetString("deny") != "" {
+			r.Response.WriteStatus(http.StatusForbidden, "denied")
+			r.ExitAll()
+		}
+	})
+	s.BindHookHandler("/*", qhttp.HOOK_AFTER_SERVE, func(r *qhttp.Request) {
+		r.Response.Write("<after")
+	})
+	var closed []string
+	s.BindHookHandler("/*", qhttp.HOOK_BEFORE_CLOSE, func(r *qhttp.Request) {
+		closed = append(closed, r.URL.RequestURI())
+	})
+	s.BindHandler("/data", func(r *qhttp.Request) {
+		r.Response.Write("data")
+	})
+	// 在HOOK方法之外调用ExitHook等同于Exit
+	s.BindHandler("/handler", func(r *qhttp.Request) {
+		r.Response.Write("handler")
+		r.ExitHook()
+		r.Response.Write("unreachable")
+	})
+	gtest.Case(t, func() {
+		_, body := request(s, "GET", "/data")
+		gtest.Assert(body, "h1>h2>data<after")
+		_, body = request(s, "GET", "/data?skip=1")
+		gtest.Assert(body, "h1>data<after")
+		code, body := request(s, "GET", "/data?deny=1")
+		gtest.Assert(code, http.StatusForbidden)
+		gtest.Assert(body, "h1>h2>denied")
+		code, body = request(s, "GET", "/handler")
+		gtest.Assert(code, http.StatusOK)
+		gtest.Assert(body, "h1>h2>handler<after")
+		// 退出请求流程之后BeforeClose仍然执行
+		gtest.Assert(closed, []string{"/data", "/data?skip=1", "/data?deny=1", "/handler"})
+	})
+}
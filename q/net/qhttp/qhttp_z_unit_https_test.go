@@ -0,0 +1,147 @@
+package qhttp_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"gf/g/test/gtest"
+	"grt/q/net/qhttp"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// 测试证书
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+// 生成测试证书，<parent>为空时生成自签名的CA证书
+func newTestCert(name string, serial int64, parent *testCert) *testCert {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	signerCert, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+	} else {
+		signerCert, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signerCert, &key.PublicKey, signerKey)
+	if err != nil {
+		panic(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCert{cert: cert, key: key, der: der}
+}
+
+// 将证书以及私钥写入文件
+func (c *testCert) write(certFile, keyFile string) {
+	ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der}), 0644)
+	if keyFile != "" {
+		keyDer, _ := x509.MarshalECPrivateKey(c.key)
+		ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+	}
+}
+
+// 转换为客户端使用的证书
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.der}, PrivateKey: c.key}
+}
+
+func TestHTTPS(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "qhttp")
+	defer os.RemoveAll(dir)
+	var (
+		ca       = newTestCert("test-ca", 1, nil)
+		caFile   = filepath.Join(dir, "ca.pem")
+		certFile = filepath.Join(dir, "server.pem")
+		keyFile  = filepath.Join(dir, "server.key")
+	)
+	ca.write(caFile, "")
+	newTestCert("server-1", 2, ca).write(certFile, keyFile)
+
+	s := newServer("https")
+	s.SetAddr("127.0.0.1:0")
+	s.SetHTTPSAddr("127.0.0.1:0")
+	s.EnableHTTPS(certFile, keyFile)
+	s.SetHTTPSRedirect(true)
+	s.SetClientAuth(caFile, tls.VerifyClientCertIfGiven)
+	s.BindHandler("/identity", func(r *qhttp.Request) {
+		r.Response.Write(r.IsHTTPS(), ":", r.GetPeerIdentity())
+	})
+	gtest.Assert(s.Start(), nil)
+	defer s.Shutdown(context.Background())
+
+	httpAddr, httpsAddr := s.ListenedAddrs()[0], s.ListenedHTTPSAddrs()[0]
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	newClient := func(certs ...tls.Certificate) *http.Client {
+		return &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig:   &tls.Config{RootCAs: pool, Certificates: certs},
+				DisableKeepAlives: true,
+			},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	}
+	get := func(client *http.Client, url string) (*http.Response, string) {
+		resp, err := client.Get(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+
+	gtest.Case(t, func() {
+		resp, _ := get(newClient(), "http://"+httpAddr+"/identity?a=1")
+		gtest.Assert(resp.StatusCode, http.StatusMovedPermanently)
+		gtest.Assert(resp.Header.Get("Location"), "https://"+httpsAddr+"/identity?a=1")
+
+		resp, body := get(newClient(), "https://"+httpsAddr+"/identity")
+		gtest.Assert(body, "true:")
+		gtest.Assert(resp.TLS.PeerCertificates[0].Subject.CommonName, "server-1")
+
+		_, body = get(newClient(newTestCert("client-1", 3, ca).tlsCertificate()), "https://"+httpsAddr+"/identity")
+		gtest.Assert(body, "true:client-1")
+	})
+	gtest.Case(t, func() {
+		// 证书文件变化之后新的连接使用新的证书
+		newTestCert("server-2", 4, ca).write(certFile, keyFile)
+		future := time.Now().Add(time.Minute)
+		os.Chtimes(certFile, future, future)
+		resp, _ := get(newClient(), "https://"+httpsAddr+"/identity")
+		gtest.Assert(resp.TLS.PeerCertificates[0].Subject.CommonName, "server-2")
+
+		// 证书文件错误时继续使用之前的证书
+		ioutil.WriteFile(certFile, []byte("invalid"), 0644)
+		future = future.Add(time.Minute)
+		os.Chtimes(certFile, future, future)
+		resp, _ = get(newClient(), "https://"+httpsAddr+"/identity")
+		gtest.Assert(resp.TLS.PeerCertificates[0].Subject.CommonName, "server-2")
+	})
+}
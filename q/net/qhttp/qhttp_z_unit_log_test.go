@@ -0,0 +1,128 @@
+package qhttp_test
+
+import (
+	"encoding/json"
+	"gf/g/test/gtest"
+	"grt/q/net/qhttp"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestLogAccess(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "qhttp")
+	defer os.RemoveAll(dir)
+	s := newServer("log-access")
+	s.SetLogPath(dir)
+	s.SetAccessLogEnabled(true)
+	s.BindHandler("/hello", func(r *qhttp.Request) {
+		r.Response.Write("hello")
+	})
+	get := func() {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "http://a.com/hello?x=1", nil)
+		req.Header.Set("User-Agent", "test-agent")
+		s.ServeHTTP(w, req)
+	}
+	gtest.Case(t, func() {
+		get()
+		content, _ := ioutil.ReadFile(filepath.Join(dir, "access.log"))
+		pattern := `^192\.0\.2\.1 - - \[.+\] "GET /hello\?x=1 HTTP/1\.1" 200 5 "" "test-agent" a\.com \d+\.\d{3}ms \d+\n$`
+		gtest.Assert(regexp.MustCompile(pattern).Match(content), true)
+	})
+	gtest.Case(t, func() {
+		os.Remove(filepath.Join(dir, "access.log"))
+		s.SetLogRotate(0, 0)
+		s.SetAccessLogFormat(qhttp.LOG_FORMAT_JSON)
+		get()
+		content, _ := ioutil.ReadFile(filepath.Join(dir, "access.log"))
+		m := make(map[string]interface{})
+		gtest.Assert(json.Unmarshal(content, &m), nil)
+		gtest.Assert(m["method"], "GET")
+		gtest.Assert(m["status"], 200)
+		gtest.Assert(m["bytes"], 5)
+		gtest.Assert(m["user_agent"], "test-agent")
+	})
+	gtest.Case(t, func() {
+		s.SetAccessLogFormat("{method} {uri} {status} {unknown}")
+		s.SetLogRotate(30, 2)
+		for i := 0; i < 4; i++ {
+			get()
+		}
+		for _, name := range []string{"access.log", "access.log.1", "access.log.2"} {
+			content, _ := ioutil.ReadFile(filepath.Join(dir, name))
+			gtest.Assert(string(content), "GET /hello?x=1 200 {unknown}\n")
+		}
+		_, err := os.Stat(filepath.Join(dir, "access.log.3"))
+		gtest.Assert(os.IsNotExist(err), true)
+	})
+}
+
+func TestLogEscape(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "qhttp")
+	defer os.RemoveAll(dir)
+	s := newServer("log-escape")
+	s.SetLogPath(dir)
+	s.SetAccessLogEnabled(true)
+	s.BindHandler("/hello", func(r *qhttp.Request) {
+		r.Response.Write("hello")
+	})
+	gtest.Case(t, func() {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "http://a.com/hello", nil)
+		req.Header.Set("User-Agent", "agent\r\n1.2.3.4 - - [fake] \"GET /admin\"")
+		req.Header.Set("Referer", `a\b`)
+		s.ServeHTTP(w, req)
+		content, _ := ioutil.ReadFile(filepath.Join(dir, "access.log"))
+		gtest.Assert(strings.Count(string(content), "\n"), 1)
+		gtest.Assert(strings.Contains(string(content), `"a\\b" "agent\x0D\x0A1.2.3.4 - - [fake] \"GET /admin\""`), true)
+	})
+}
+
+func TestLogError(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "qhttp")
+	defer os.RemoveAll(dir)
+	s := newServer("log-error")
+	s.SetLogPath(dir)
+	s.BindHandler("/panic", func(r *qhttp.Request) {
+		r.Response.Write("partial")
+		panic("something wrong")
+	})
+	gtest.Case(t, func() {
+		code, body := request(s, "GET", "/panic")
+		gtest.Assert(code, http.StatusInternalServerError)
+		gtest.Assert(body, "Internal Server Error")
+		content, _ := ioutil.ReadFile(filepath.Join(dir, "error.log"))
+		gtest.Assert(strings.Contains(string(content), "GET example.com /panic: something wrong"), true)
+		gtest.Assert(strings.Contains(string(content), "qhttp_z_unit_log_test.go"), true)
+	})
+}
+
+func TestLogCloseHookPanic(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "qhttp")
+	defer os.RemoveAll(dir)
+	s := newServer("log-close-hook")
+	s.SetLogPath(dir)
+	s.SetAccessLogEnabled(true)
+	s.SetAccessLogFormat("{method} {uri} {status}")
+	s.BindHookHandler("/*", qhttp.HOOK_BEFORE_CLOSE, func(r *qhttp.Request) {
+		panic("close failed")
+	})
+	s.BindHandler("/hello", func(r *qhttp.Request) {
+		r.Response.Write("hello")
+	})
+	gtest.Case(t, func() {
+		code, body := request(s, "GET", "/hello")
+		gtest.Assert(code, http.StatusOK)
+		gtest.Assert(body, "hello")
+		content, _ := ioutil.ReadFile(filepath.Join(dir, "access.log"))
+		gtest.Assert(string(content), "GET /hello 200\n")
+		content, _ = ioutil.ReadFile(filepath.Join(dir, "error.log"))
+		gtest.Assert(strings.Contains(string(content), "GET example.com /hello: close failed"), true)
+	})
+}
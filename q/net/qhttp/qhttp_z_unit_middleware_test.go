@@ -0,0 +1,70 @@
+package qhttp_test
+
+import (
+	"gf/g/test/gtest"
+	"grt/q/net/qhttp"
+	"strings"
+	"testing"
+)
+
+// 创建在处理方法前后分别写入标记的中间件
+func mark(name string) qhttp.HandlerFunc {
+	return func(r *qhttp.Request) {
+		r.Response.Write(name + ">")
+		r.Middleware.Next()
+		r.Response.Write("<" + name)
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	s := newServer("middleware-order")
+	s.Use(mark("g1"), mark("g2"))
+	s.BindMiddleware("/api/*", mark("r1"))
+	s.Group("/api", func(g *qhttp.RouterGroup) {
+		g.Middleware(mark("m1"))
+		g.GET("/user", func(r *qhttp.Request) {
+			r.Response.Write("user")
+		})
+	})
+	s.BindHandler("/plain", func(r *qhttp.Request) {
+		r.Response.Write("plain")
+	})
+	gtest.Case(t, func() {
+		_, body := request(s, "GET", "/api/user")
+		gtest.Assert(body, "g1>g2>m1>r1>user<r1<m1<g2<g1")
+		_, body = request(s, "GET", "/plain")
+		gtest.Assert(body, "g1>g2>plain<g2<g1")
+	})
+}
+
+func TestMiddlewareExit(t *testing.T) {
+	s := newServer("middleware-exit")
+	s.Use(mark("g1"))
+	s.Group("/", func(g *qhttp.RouterGroup) {
+		g.Middleware(func(r *qhttp.Request) {
+			if r.GetString("token") == "" {
+				r.Response.Write("denied")
+				r.ExitAll()
+			}
+			r.Middleware.Next()
+		})
+		g.ALL("/data", func(r *qhttp.Request) {
+			r.Response.Write("data")
+		})
+	})
+	s.BindHandler("/rewrite", func(r *qhttp.Request) {
+		r.Response.Write("content")
+	})
+	s.BindMiddleware("/rewrite", func(r *qhttp.Request) {
+		r.Middleware.Next()
+		r.Response.SetBuffer([]byte(strings.ToUpper(r.Response.BufferString())))
+	})
+	gtest.Case(t, func() {
+		_, body := request(s, "GET", "/data?token=1")
+		gtest.Assert(body, "g1>data<g1")
+		_, body = request(s, "GET", "/data")
+		gtest.Assert(body, "g1>denied<g1")
+		_, body = request(s, "GET", "/rewrite")
+		gtest.Assert(body, "G1>CONTENT<g1")
+	})
+}
@@ -0,0 +1,144 @@
+package qhttp_test
+
+import (
+	"gf/g/test/gtest"
+	"grt/q/net/qhttp"
+	"testing"
+)
+
+// 提交指定类型的内容到服务并返回内容
+func requestBody(s *qhttp.Server, method, uri, contentType, body string) string {
+	return serveRequest(s, method, uri, body, map[string]string{"Content-Type": contentType}).Body.String()
+}
+
+func TestParamQuery(t *testing.T) {
+	s := newServer("param-query")
+	s.BindHandler("/query", func(r *qhttp.Request) {
+		r.Response.Write(r.GetQueryString("name"), "|",
+			r.GetInt("age"), "|",
+			r.GetStrings("ids"), "|",
+			r.GetMap("user")["info"], "|",
+			r.GetString("none", "def"),
+		)
+	})
+	gtest.Case(t, func() {
+		_, body := request(s, "GET", "/query?name=john&age=18&ids[]=1&ids[]=2&user[info][city]=sz")
+		gtest.Assert(body, `john|18|["1","2"]|{"city":"sz"}|def`)
+	})
+}
+
+func TestParamInt(t *testing.T) {
+	s := newServer("param-int")
+	s.BindHandler("/int", func(r *qhttp.Request) {
+		r.Response.Write(r.GetInt64("v"))
+	})
+	gtest.Case(t, func() {
+		// 只按照十进制解析，超出范围以及无法转换时为0
+		for v, expect := range map[string]string{
+			"010":                  "10",
+			"08":                   "8",
+			"-5":                   "-5",
+			"0x1f":                 "0",
+			"1_000":                "0",
+			"1.9":                  "1",
+			"1e3":                  "1000",
+			"99999999999999999999": "0",
+			"1e30":                 "0",
+		} {
+			_, body := request(s, "GET", "/int?v="+v)
+			gtest.Assert(body, expect)
+		}
+	})
+}
+
+func TestParamPost(t *testing.T) {
+	s := newServer("param-post")
+	s.BindHandler("/:id", func(r *qhttp.Request) {
+		r.Response.Write(r.GetString("id"), "|",
+			r.GetString("name"), "|",
+			r.GetFloat64("price"), "|",
+			r.GetQueryString("id"), "|",
+			r.GetRawString(),
+		)
+	})
+	gtest.Case(t, func() {
+		body := requestBody(s, "POST", "/1?id=2&name=query", "application/x-www-form-urlencoded", "name=form&price=1.5")
+		gtest.Assert(body, "1|form|1.5|2|name=form&price=1.5")
+		body = requestBody(s, "POST", "/1", "application/json", `{"name":"json","price":9.99}`)
+		gtest.Assert(body, `1|json|9.99||{"name":"json","price":9.99}`)
+		body = requestBody(s, "POST", "/1", "", `{"name":"auto"}`)
+		gtest.Assert(body, `1|auto|0||{"name":"auto"}`)
+	})
+}
+
+func TestParamMultipart(t *testing.T) {
+	s := newServer("param-multipart")
+	s.BindHandler("/", func(r *qhttp.Request) {
+		r.Response.Write(r.GetFormString("name"), "|", r.GetPostMap()["tags"])
+	})
+	gtest.Case(t, func() {
+		content := "--B\r\nContent-Disposition: form-data; name=\"name\"\r\n\r\njohn\r\n" +
+			"--B\r\nContent-Disposition: form-data; name=\"tags[]\"\r\n\r\na\r\n" +
+			"--B\r\nContent-Disposition: form-data; name=\"tags[]\"\r\n\r\nb\r\n--B--\r\n"
+		body := requestBody(s, "POST", "/", "multipart/form-data; boundary=B", content)
+		gtest.Assert(body, `john|["a","b"]`)
+	})
+}
+
+func TestParamStruct(t *testing.T) {
+	type Address struct {
+		City string `p:"city"`
+		Zip  int
+	}
+	type User struct {
+		Id       int
+		UserName string   `p:"name"`
+		Tags     []string `p:"tags"`
+		Address  Address  `p:"addr"`
+		Items    []struct {
+			Price float64
+		}
+	}
+	s := newServer("param-struct")
+	s.BindHandler("/user/:id", func(r *qhttp.Request) {
+		user := new(User)
+		if err := r.Parse(user); err != nil {
+			r.Response.Write(err.Error())
+			return
+		}
+		r.Response.Writef("%+v", *user)
+	})
+	gtest.Case(t, func() {
+		body := requestBody(s, "POST", "/user/1?name=john", "application/json",
+			`{"tags":["a","b"],"addr":{"city":"sz","zip":"518000"},"items":[{"price":1.5}]}`)
+		gtest.Assert(body, "{Id:1 UserName:john Tags:[a b] Address:{City:sz Zip:518000} Items:[{Price:1.5}]}")
+		body = requestBody(s, "POST", "/user/x", "application/x-www-form-urlencoded",
+			"addr[zip]=abc&items[][price]=1&items[][price]=y")
+		gtest.Assert(body, `Id: cannot convert "x" to integer; Address.Zip: cannot convert "abc" to integer; Items[1].Price: cannot convert "y" to float`)
+		// 超出范围以及带有小数部分的值不会被静默转换
+		body = requestBody(s, "POST", "/user/99999999999999999999", "application/json", `{"addr":{"zip":1.9}}`)
+		gtest.Assert(body, `Id: value "99999999999999999999" overflows int; Address.Zip: cannot convert "1.9" to integer`)
+		body = requestBody(s, "POST", "/user/1.0", "application/x-www-form-urlencoded", "addr[zip]=1.5")
+		gtest.Assert(body, `Address.Zip: cannot convert "1.5" to integer`)
+	})
+}
+
+func TestParamValid(t *testing.T) {
+	type Register struct {
+		Name      string `v:"required|length:2,8"`
+		Password  string `p:"pass" v:"required|length:6,16#请输入密码|密码长度为:min到:max位"`
+		Password2 string `p:"pass2" v:"same:pass#两次密码不一致"`
+	}
+	s := newServer("param-valid")
+	s.BindHandler("/register", func(r *qhttp.Request) {
+		req := new(Register)
+		r.ParseOrExit(req)
+		r.Response.Write("ok")
+	})
+	gtest.Case(t, func() {
+		body := requestBody(s, "POST", "/register", "", "name=john&pass=123456&pass2=123456")
+		gtest.Assert(body, "ok")
+		body = requestBody(s, "POST", "/register", "", "name=j&pass=123&pass2=1234")
+		gtest.Assert(body, "The Name value length must be between 2 and 8; 密码长度为6到16位; 两次密码不一致")
+	})
+}
@@ -0,0 +1,239 @@
+package qhttp_test
+
+import (
+	"errors"
+	"gf/g/test/gtest"
+	"grt/q/net/qhttp"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 以客户端<ip>请求服务，<headers>为请求头的名称以及值，服务需要信任httptest请求的直连地址192.0.2.1
+func rateLimitRequest(s *qhttp.Server, uri, ip string, headers ...string) *httptest.ResponseRecorder {
+	m := map[string]string{"X-Forwarded-For": ip}
+	for i := 0; i+1 < len(headers); i += 2 {
+		m[headers[i]] = headers[i+1]
+	}
+	return serveRequest(s, "GET", uri, "", m)
+}
+
+func TestRateLimitTokenBucket(t *testing.T) {
+	s := newServer("ratelimit-token")
+	s.SetTrustedProxies("192.0.2.1")
+	s.Group("/api", func(g *qhttp.RouterGroup) {
+		g.RateLimit(qhttp.RateLimitConfig{Limit: 2, Period: time.Hour})
+		g.GET("/user", func(r *qhttp.Request) {
+			r.Response.Write("ok")
+		})
+	})
+	gtest.Case(t, func() {
+		w := rateLimitRequest(s, "/api/user", "10.0.0.1")
+		gtest.Assert(w.Body.String(), "ok")
+		gtest.Assert(w.Header().Get("RateLimit-Limit"), "2")
+		gtest.Assert(w.Header().Get("RateLimit-Remaining"), "1")
+		w = rateLimitRequest(s, "/api/user", "10.0.0.1")
+		gtest.Assert(w.Body.String(), "ok")
+		gtest.Assert(w.Header().Get("RateLimit-Remaining"), "0")
+
+		w = rateLimitRequest(s, "/api/user", "10.0.0.1")
+		gtest.Assert(w.Code, http.StatusTooManyRequests)
+		gtest.Assert(w.Header().Get("Retry-After"), "1800")
+		gtest.Assert(w.Header().Get("RateLimit-Reset"), "3600")
+
+		// 不同的客户端IP互不影响
+		w = rateLimitRequest(s, "/api/user", "10.0.0.2")
+		gtest.Assert(w.Body.String(), "ok")
+	})
+}
+
+func TestRateLimitSlidingWindow(t *testing.T) {
+	s := newServer("ratelimit-window")
+	s.SetTrustedProxies("192.0.2.1")
+	s.Use(qhttp.MiddlewareRateLimit(qhttp.RateLimitConfig{
+		Algorithm: qhttp.RATE_LIMIT_SLIDING_WINDOW,
+		Limit:     3,
+		Period:    time.Hour,
+		KeyFunc:   qhttp.RateLimitKeyByHeader("X-Api-Key"),
+	}))
+	s.BindHandler("/", func(r *qhttp.Request) {
+		r.Response.Write("ok")
+	})
+	gtest.Case(t, func() {
+		for i := 0; i < 3; i++ {
+			w := rateLimitRequest(s, "/", "10.0.0.1", "X-Api-Key", "a")
+			gtest.Assert(w.Body.String(), "ok")
+		}
+		w := rateLimitRequest(s, "/", "10.0.0.2", "X-Api-Key", "a")
+		gtest.Assert(w.Code, http.StatusTooManyRequests)
+		gtest.Assert(w.Header().Get("RateLimit-Remaining"), "0")
+		retryAfter, _ := strconv.Atoi(w.Header().Get("Retry-After"))
+		gtest.AssertGT(retryAfter, 0)
+		gtest.AssertLT(retryAfter, 7201)
+
+		w = rateLimitRequest(s, "/", "10.0.0.1", "X-Api-Key", "b")
+		gtest.Assert(w.Body.String(), "ok")
+		// 没有请求头时按照客户端IP限流
+		w = rateLimitRequest(s, "/", "10.0.0.1")
+		gtest.Assert(w.Body.String(), "ok")
+	})
+}
+
+// 记录调用次数的限流存储，用于测试自定义存储
+type rateLimitStorageStub struct {
+	keys []string
+	err  error
+}
+
+func (s *rateLimitStorageStub) Take(key string, rule qhttp.RateLimitRule) (qhttp.RateLimitResult, error) {
+	s.keys = append(s.keys, key)
+	return qhttp.RateLimitResult{Allowed: false, RetryAfter: 1500 * time.Millisecond}, s.err
+}
+
+func TestRateLimitStorage(t *testing.T) {
+	storage := &rateLimitStorageStub{}
+	s := newServer("ratelimit-storage")
+	s.SetTrustedProxies("192.0.2.1")
+	s.Use(qhttp.MiddlewareRateLimit(qhttp.RateLimitConfig{
+		Name:    "api",
+		Limit:   10,
+		KeyFunc: qhttp.RateLimitKeyByRoute,
+		Storage: storage,
+	}))
+	s.BindHandler("GET:/user/:id", func(r *qhttp.Request) {
+		r.Response.Write("ok")
+	})
+	gtest.Case(t, func() {
+		w := rateLimitRequest(s, "/user/1", "10.0.0.1")
+		gtest.Assert(w.Code, http.StatusTooManyRequests)
+		gtest.Assert(w.Header().Get("Retry-After"), "2")
+		rateLimitRequest(s, "/user/2", "10.0.0.2")
+		gtest.Assert(storage.keys, []string{"api:GET:/user/:id@", "api:GET:/user/:id@"})
+
+		// 存储出错时允许请求
+		storage.err = errors.New("unavailable")
+		w = rateLimitRequest(s, "/user/1", "10.0.0.1")
+		gtest.Assert(w.Body.String(), "ok")
+	})
+}
+
+func TestRateLimitStorageMemoryConcurrent(t *testing.T) {
+	storage := qhttp.NewRateLimitStorageMemory()
+	for _, algorithm := range []string{qhttp.RATE_LIMIT_TOKEN_BUCKET, qhttp.RATE_LIMIT_SLIDING_WINDOW} {
+		gtest.Case(t, func() {
+			var (
+				wg      sync.WaitGroup
+				allowed int32
+				rule    = qhttp.RateLimitRule{Algorithm: algorithm, Limit: 50, Period: time.Hour}
+			)
+			for i := 0; i < 100; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if result, _ := storage.Take(algorithm, rule); result.Allowed {
+						atomic.AddInt32(&allowed, 1)
+					}
+				}()
+			}
+			wg.Wait()
+			gtest.Assert(allowed, 50)
+		})
+	}
+}
+
+// 创建使用可控时钟的内存限流存储，返回存储以及修改当前时间的方法
+func newRateLimitStorageClock(start time.Time) (*qhttp.RateLimitStorageMemory, func(d time.Duration)) {
+	now := start
+	s := qhttp.NewRateLimitStorageMemory(func() time.Time {
+		return now
+	})
+	return s, func(d time.Duration) {
+		now = now.Add(d)
+	}
+}
+
+func TestRateLimitStorageMemoryTokenBucket(t *testing.T) {
+	s, advance := newRateLimitStorageClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	rule := qhttp.RateLimitRule{Algorithm: qhttp.RATE_LIMIT_TOKEN_BUCKET, Limit: 2, Period: 200 * time.Millisecond}
+	gtest.Case(t, func() {
+		result, _ := s.Take("a", rule)
+		gtest.Assert(result.Allowed, true)
+		gtest.Assert(result.Remaining, 1)
+		result, _ = s.Take("a", rule)
+		gtest.Assert(result.Allowed, true)
+		gtest.Assert(result.Remaining, 0)
+		gtest.Assert(result.Reset.Round(time.Millisecond), 200*time.Millisecond)
+		result, _ = s.Take("a", rule)
+		gtest.Assert(result.Allowed, false)
+		gtest.Assert(result.RetryAfter.Round(time.Millisecond), 100*time.Millisecond)
+
+		// 每100毫秒补充一个令牌
+		advance(150 * time.Millisecond)
+		result, _ = s.Take("a", rule)
+		gtest.Assert(result.Allowed, true)
+		gtest.Assert(result.Remaining, 0)
+		result, _ = s.Take("a", rule)
+		gtest.Assert(result.Allowed, false)
+		gtest.Assert(result.RetryAfter.Round(time.Millisecond), 50*time.Millisecond)
+
+		// 令牌最多补满桶的容量
+		advance(time.Hour)
+		result, _ = s.Take("a", rule)
+		gtest.Assert(result.Allowed, true)
+		gtest.Assert(result.Remaining, 1)
+	})
+}
+
+func TestRateLimitStorageMemorySlidingWindow(t *testing.T) {
+	s, advance := newRateLimitStorageClock(time.Date(2026, 1, 1, 0, 30, 0, 0, time.UTC))
+	rule := qhttp.RateLimitRule{Algorithm: qhttp.RATE_LIMIT_SLIDING_WINDOW, Limit: 3, Period: time.Hour}
+	gtest.Case(t, func() {
+		for i := 0; i < 3; i++ {
+			result, _ := s.Take("a", rule)
+			gtest.Assert(result.Allowed, true)
+			gtest.Assert(result.Remaining, 2-i)
+			gtest.Assert(result.Reset, 30*time.Minute)
+		}
+		// 01:20时上一个窗口的权重为2/3，估算的请求数量降低到2
+		result, _ := s.Take("a", rule)
+		gtest.Assert(result.Allowed, false)
+		gtest.Assert(result.RetryAfter.Round(time.Second), 50*time.Minute)
+
+		advance(50*time.Minute - time.Second)
+		result, _ = s.Take("a", rule)
+		gtest.Assert(result.Allowed, false)
+		gtest.Assert(result.RetryAfter.Round(time.Second), time.Second)
+		advance(time.Second + time.Millisecond)
+		result, _ = s.Take("a", rule)
+		gtest.Assert(result.Allowed, true)
+		gtest.Assert(result.Remaining, 0)
+		gtest.Assert(result.Reset.Round(time.Second), 40*time.Minute)
+
+		// 超过两个周期没有请求时与新的key一致
+		advance(2 * time.Hour)
+		result, _ = s.Take("a", rule)
+		gtest.Assert(result.Allowed, true)
+		gtest.Assert(result.Remaining, 2)
+	})
+}
+
+func TestRateLimitStorageMemoryWindowBoundary(t *testing.T) {
+	s, advance := newRateLimitStorageClock(time.Date(2026, 1, 1, 0, 59, 59, 0, time.UTC))
+	rule := qhttp.RateLimitRule{Algorithm: qhttp.RATE_LIMIT_SLIDING_WINDOW, Limit: 3, Period: time.Hour}
+	gtest.Case(t, func() {
+		for i := 0; i < 3; i++ {
+			result, _ := s.Take("a", rule)
+			gtest.Assert(result.Allowed, true)
+		}
+		// 跨越窗口边界之后上一个窗口的请求仍然计入
+		advance(2 * time.Second)
+		result, _ := s.Take("a", rule)
+		gtest.Assert(result.Allowed, false)
+		gtest.AssertGT(result.RetryAfter, 0)
+		gtest.AssertLT(result.RetryAfter, time.Hour)
+	})
+}
@@ -0,0 +1,97 @@
+package qhttp_test
+
+import (
+	"gf/g/test/gtest"
+	"grt/q/net/qhttp"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestExit(t *testing.T) {
+	s := newServer("request-exit")
+	s.BindHandler("/exit", func(r *qhttp.Request) {
+		defer r.Response.Write("|deferred")
+		r.Response.Write("before")
+		r.Exit()
+		r.Response.Write("after")
+	})
+	s.BindHandler("/exit-all", func(r *qhttp.Request) {
+		r.Response.Write("before")
+		r.ExitAll()
+		r.Response.Write("after")
+	})
+	// 与退出异常内容相同的普通panic按照错误处理
+	s.SetErrorLogEnabled(false)
+	s.BindHandler("/panic", func(r *qhttp.Request) {
+		panic("exit")
+	})
+	gtest.Case(t, func() {
+		_, body := request(s, "GET", "/exit")
+		gtest.Assert(body, "before|deferred")
+		_, body = request(s, "GET", "/exit-all")
+		gtest.Assert(body, "before")
+		code, _ := request(s, "GET", "/panic")
+		gtest.Assert(code, http.StatusInternalServerError)
+	})
+}
+
+func TestRequestClientIp(t *testing.T) {
+	s := newServer("request-client-ip")
+	gtest.Assert(s.SetTrustedProxies("10.0.0.0/8", "::1") == nil, true)
+	gtest.Assert(s.SetTrustedProxies("10.0.0.0/33") == nil, false)
+	s.BindHandler("/ip", func(r *qhttp.Request) {
+		r.Response.Write(r.GetClientIp() + "|" + r.GetRemoteIp())
+	})
+	gtest.Case(t, func() {
+		get := func(remote string, headers map[string]string) string {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/ip", nil)
+			req.RemoteAddr = remote
+			for k, v := range headers {
+				req.Header.Set(k, v)
+			}
+			s.ServeHTTP(w, req)
+			return w.Body.String()
+		}
+		xff := map[string]string{"X-Forwarded-For": "1.1.1.1, 2.2.2.2, 10.0.0.2"}
+		gtest.Assert(get("3.3.3.3:1000", xff), "3.3.3.3|3.3.3.3")
+		gtest.Assert(get("10.0.0.1:1000", xff), "2.2.2.2|10.0.0.1")
+		gtest.Assert(get("[::1]:1000", xff), "2.2.2.2|::1")
+		gtest.Assert(get("10.0.0.1:1000", map[string]string{"X-Forwarded-For": "10.0.0.3, 10.0.0.2"}), "10.0.0.3|10.0.0.1")
+		gtest.Assert(get("10.0.0.1:1000", map[string]string{"X-Forwarded-For": "1.1.1.1, bad"}), "10.0.0.1|10.0.0.1")
+		gtest.Assert(get("10.0.0.1:1000", map[string]string{"X-Real-IP": "4.4.4.4"}), "4.4.4.4|10.0.0.1")
+		gtest.Assert(get("10.0.0.1:1000", map[string]string{
+			"Forwarded":       `for=5.5.5.5;proto=https, for="[2001:db8::1]:4711"`,
+			"X-Forwarded-For": "1.1.1.1",
+		}), "2001:db8::1|10.0.0.1")
+		gtest.Assert(get("10.0.0.1:1000", map[string]string{"Forwarded": `for="10.0.0.5:80", for=10.0.0.6`}), "10.0.0.5|10.0.0.1")
+		gtest.Assert(get("10.0.0.1:1000", map[string]string{"Forwarded": "for=unknown"}), "10.0.0.1|10.0.0.1")
+		gtest.Assert(get("10.0.0.1:1000", map[string]string{"Forwarded": "proto=http"}), "10.0.0.1|10.0.0.1")
+	})
+}
+
+func TestRequestCookie(t *testing.T) {
+	s := newServer("request-cookie")
+	s.BindHandler("/cookie", func(r *qhttp.Request) {
+		r.Response.Write(r.Cookie.Get("a"), "|", r.Cookie.Get("none", "def"), "|", r.Cookie.Contains("b"))
+		r.Cookie.Set("c", "3")
+		r.Cookie.SetCookie("d", "4", "", "/admin", 0, true, true, http.SameSiteStrictMode)
+		r.Cookie.Remove("b")
+		r.Response.Write("|", r.Cookie.Contains("b"), "|", len(r.Cookie.Map()))
+	})
+	gtest.Case(t, func() {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "http://www.a.com:8080/cookie", nil)
+		req.Header.Set("Cookie", "a=1; b=2")
+		s.ServeHTTP(w, req)
+		gtest.Assert(w.Body.String(), "1|def|true|false|3")
+		cookies := w.Header()["Set-Cookie"]
+		gtest.Assert(len(cookies), 3)
+		gtest.Assert(cookies[0], "b=; Path=/; Domain=www.a.com; Expires=Thu, 01 Jan 1970 00:00:00 GMT; Max-Age=0")
+		gtest.Assert(strings.HasPrefix(cookies[1], "c=3; Path=/; Domain=www.a.com; Expires="), true)
+		gtest.Assert(strings.HasSuffix(cookies[1], "; Max-Age=31536000"), true)
+		gtest.Assert(cookies[2], "d=4; Path=/admin; HttpOnly; Secure; SameSite=Strict")
+	})
+}
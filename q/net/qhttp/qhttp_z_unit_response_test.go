@@ -0,0 +1,71 @@
+package qhttp_test
+
+import (
+	"gf/g/test/gtest"
+	"grt/q/net/qhttp"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResponseWrite(t *testing.T) {
+	s := newServer("response-write")
+	s.BindHandler("/json", func(r *qhttp.Request) {
+		r.Response.WriteJsonP(map[string]interface{}{"id": 1})
+	})
+	s.BindHandler("/xml", func(r *qhttp.Request) {
+		r.Response.WriteXml(map[string]interface{}{"id": 1, "name": "<john>"}, "user")
+	})
+	s.BindHandler("/status", func(r *qhttp.Request) {
+		r.Response.Write("buffered")
+		r.Response.SetBuffer([]byte("rewritten"))
+		r.Response.WriteStatus(http.StatusAccepted)
+	})
+	s.BindHandler("/redirect", func(r *qhttp.Request) {
+		r.Response.RedirectBack()
+	})
+	gtest.Case(t, func() {
+		_, body := request(s, "GET", "/json")
+		gtest.Assert(body, `{"id":1}`)
+		_, body = request(s, "GET", "/json?callback=cb")
+		gtest.Assert(body, `cb({"id":1})`)
+		_, body = request(s, "GET", "/json?callback=alert(1)")
+		gtest.Assert(body, `{"id":1}`)
+		_, body = request(s, "GET", "/xml")
+		gtest.Assert(body, `<user><id>1</id><name>&lt;john&gt;</name></user>`)
+		code, body := request(s, "GET", "/status")
+		gtest.Assert(code, http.StatusAccepted)
+		gtest.Assert(body, "rewritten")
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/redirect", nil)
+		req.Header.Set("Referer", "/from")
+		s.ServeHTTP(w, req)
+		gtest.Assert(w.Code, http.StatusFound)
+		gtest.Assert(w.Header().Get("Location"), "/from")
+	})
+}
+
+func TestResponseServeFileDownload(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "qhttp")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "data.txt")
+	ioutil.WriteFile(path, []byte("0123456789"), 0644)
+
+	s := newServer("response-download")
+	s.BindHandler("/download", func(r *qhttp.Request) {
+		r.Response.ServeFileDownload(path, "报表.txt")
+	})
+	gtest.Case(t, func() {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/download", nil)
+		req.Header.Set("Range", "bytes=2-4")
+		s.ServeHTTP(w, req)
+		gtest.Assert(w.Code, http.StatusPartialContent)
+		gtest.Assert(w.Body.String(), "234")
+		gtest.Assert(w.Header().Get("Content-Disposition"), "attachment; filename*=utf-8''%E6%8A%A5%E8%A1%A8.txt")
+	})
+}
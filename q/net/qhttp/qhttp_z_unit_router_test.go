@@ -0,0 +1,195 @@
+package qhttp_test
+
+import (
+	"gf/g/test/gtest"
+	"grt/q/net/qhttp"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// 请求指定的服务并返回状态码以及内容
+func request(s *qhttp.Server, method, uri string) (int, string) {
+	w := serveRequest(s, method, uri, "", nil)
+	return w.Code, w.Body.String()
+}
+
+// 使用请求内容<body>以及请求头<headers>请求指定的服务，返回完整的返回结果，值为空的请求头会被忽略
+func serveRequest(s *qhttp.Server, method, uri, body string, headers map[string]string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, uri, strings.NewReader(body))
+	for k, v := range headers {
+		if v != "" {
+			req.Header.Set(k, v)
+		}
+	}
+	s.ServeHTTP(w, req)
+	return w
+}
+
+func TestRouterPattern(t *testing.T) {
+	s := newServer("router-pattern")
+	s.BindHandler("/user/list", func(r *qhttp.Request) {
+		r.Response.Write("list")
+	})
+	s.BindHandler("/user/:id", func(r *qhttp.Request) {
+		r.Response.Write("id:"+r.GetRouterString("id"))
+	})
+	s.BindHandler("/user/{uid:\\d+}", func(r *qhttp.Request) {
+		r.Response.Write("uid:"+r.GetRouterString("uid"))
+	})
+	s.BindHandler("/page/{page}.html", func(r *qhttp.Request) {
+		r.Response.Write("page:"+r.GetRouterString("page"))
+	})
+	s.BindHandler("/files/*path", func(r *qhttp.Request) {
+		r.Response.Write("path:"+r.GetRouterString("path"))
+	})
+	s.BindHandler("/{name}/profile", func(r *qhttp.Request) {
+		r.Response.Write("name:"+r.GetRouterString("name"))
+	})
+	gtest.Case(t, func() {
+		_, body := request(s, "GET", "/user/list")
+		gtest.Assert(body, "list")
+		_, body = request(s, "GET", "/user/100")
+		gtest.Assert(body, "uid:100")
+		_, body = request(s, "GET", "/user/john")
+		gtest.Assert(body, "id:john")
+		_, body = request(s, "GET", "/page/10.html")
+		gtest.Assert(body, "page:10")
+		_, body = request(s, "GET", "/files/a/b/c.txt")
+		gtest.Assert(body, "path:a/b/c.txt")
+		_, body = request(s, "GET", "/files")
+		gtest.Assert(body, "path:")
+		_, body = request(s, "GET", "/john/profile")
+		gtest.Assert(body, "name:john")
+		code, _ := request(s, "GET", "/none/none/none")
+		gtest.Assert(code, http.StatusNotFound)
+	})
+}
+
+func TestRouterBacktrack(t *testing.T) {
+	s := newServer("router-backtrack")
+	s.BindHandler("/user/list/all", func(r *qhttp.Request) {
+		r.Response.Write("all")
+	})
+	s.BindHandler("/user/:id/:action", func(r *qhttp.Request) {
+		r.Response.Write(r.GetRouterString("id")+"-"+r.GetRouterString("action"))
+	})
+	gtest.Case(t, func() {
+		_, body := request(s, "GET", "/user/list/all")
+		gtest.Assert(body, "all")
+		// 静态节点未能完全匹配时回溯到命名节点
+		_, body = request(s, "GET", "/user/list/edit")
+		gtest.Assert(body, "list-edit")
+	})
+}
+
+func TestRouterMethod(t *testing.T) {
+	s := newServer("router-method")
+	s.BindHandler("GET:/order/:id", func(r *qhttp.Request) {
+		r.Response.Write("get")
+	})
+	s.BindHandler("PUT,DELETE:/order/:id", func(r *qhttp.Request) {
+		r.Response.Write("modify:"+r.Method)
+	})
+	gtest.Case(t, func() {
+		_, body := request(s, "GET", "/order/1")
+		gtest.Assert(body, "get")
+		_, body = request(s, "DELETE", "/order/1")
+		gtest.Assert(body, "modify:DELETE")
+		code, _ := request(s, "POST", "/order/1")
+		gtest.Assert(code, http.StatusMethodNotAllowed)
+	})
+}
+
+type testObject struct{}
+
+func (o *testObject) Init(r *qhttp.Request) { r.Response.Write("init>") }
+
+func (o *testObject) Shut(r *qhttp.Request) { r.Response.Write("<shut") }
+
+func (o *testObject) Index(r *qhttp.Request) { r.Response.Write("index") }
+
+func (o *testObject) ShowList(r *qhttp.Request) { r.Response.Write("show-list") }
+
+func (o *testObject) Exit(r *qhttp.Request) {
+	r.Response.Write("exit")
+	r.Exit()
+	r.Response.Write("unreachable")
+}
+
+type testRest struct{}
+
+func (o *testRest) Get(r *qhttp.Request) { r.Response.Write("get") }
+
+func (o *testRest) Post(r *qhttp.Request) { r.Response.Write("post") }
+
+func TestRouterGroupObject(t *testing.T) {
+	s := newServer("router-group-object")
+	s.Group("/api", func(g *qhttp.RouterGroup) {
+		g.Middleware(mark("m1"))
+		g.Group("/v1", func(g *qhttp.RouterGroup) {
+			g.Middleware(mark("m2"))
+			g.GET("/user", func(r *qhttp.Request) {
+				r.Response.Write("user")
+			})
+			g.Object("/obj", new(testObject))
+			g.REST("/rest", new(testRest))
+		})
+	})
+	s.BindObject("/object/{method}", new(testObject), "ShowList")
+	gtest.Case(t, func() {
+		_, body := request(s, "GET", "/api/v1/user")
+		gtest.Assert(body, "m1>m2>user<m2<m1")
+		_, body = request(s, "GET", "/api/v1/obj")
+		gtest.Assert(body, "m1>m2>init>index<shut<m2<m1")
+		_, body = request(s, "GET", "/api/v1/obj/show-list")
+		gtest.Assert(body, "m1>m2>init>show-list<shut<m2<m1")
+		// 方法退出时同样调用Shut
+		_, body = request(s, "GET", "/api/v1/obj/exit")
+		gtest.Assert(body, "m1>m2>init>exit<shut<m2<m1")
+		_, body = request(s, "POST", "/api/v1/rest")
+		gtest.Assert(body, "m1>m2>post<m2<m1")
+		code, _ := request(s, "DELETE", "/api/v1/rest")
+		gtest.Assert(code, http.StatusMethodNotAllowed)
+		_, body = request(s, "GET", "/object/show-list")
+		gtest.Assert(body, "init>show-list<shut")
+		code, _ = request(s, "GET", "/object/index")
+		gtest.Assert(code, http.StatusNotFound)
+	})
+}
+
+func TestRouterDomain(t *testing.T) {
+	s := newServer("router-domain")
+	s.BindHandler("/", func(r *qhttp.Request) {
+		r.Response.Write("default")
+	})
+	s.Domain("a.com, B.com").BindHandler("/", func(r *qhttp.Request) {
+		r.Response.Write("domain:" + r.GetHost())
+	})
+	s.Domain("c.com").Group("/api", func(g *qhttp.RouterGroup) {
+		g.GET("/info", func(r *qhttp.Request) {
+			r.Response.Write("info")
+		})
+	})
+	gtest.Case(t, func() {
+		get := func(host, uri string) (int, string) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", uri, nil)
+			req.Host = host
+			s.ServeHTTP(w, req)
+			return w.Code, w.Body.String()
+		}
+		_, body := get("a.com", "/")
+		gtest.Assert(body, "domain:a.com")
+		_, body = get("b.com:8080", "/")
+		gtest.Assert(body, "domain:b.com")
+		_, body = get("d.com", "/")
+		gtest.Assert(body, "default")
+		_, body = get("c.com", "/api/info")
+		gtest.Assert(body, "info")
+		code, _ := get("a.com", "/api/info")
+		gtest.Assert(code, http.StatusNotFound)
+	})
+}
@@ -0,0 +1,152 @@
+package qhttp_test
+
+import (
+	"context"
+	"gf/g/test/gtest"
+	"grt/q/net/qhttp"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 测试中创建的服务数量，用于生成唯一的服务名称
+var serverCount int32
+
+// 创建一个以<name>为前缀且名称唯一的服务，
+// 服务对象按照名称全局注册，重复执行测试(例如-count=2)时不会复用已经注册过路由的服务
+func newServer(name string) *qhttp.Server {
+	return qhttp.GetServer(name + "-" + strconv.Itoa(int(atomic.AddInt32(&serverCount, 1))))
+}
+
+// 启动一个在请求中等待<delay>的服务，返回服务对象以及访问地址
+func startSlowServer(name string, delay time.Duration) (*qhttp.Server, string) {
+	s := newServer(name)
+	s.SetAddr("127.0.0.1:0")
+	s.BindHandler("/slow", func(r *qhttp.Request) {
+		time.Sleep(delay)
+		r.Response.Write("done")
+	})
+	if err := s.Start(); err != nil {
+		panic(err)
+	}
+	return s, "http://" + s.ListenedAddrs()[0]
+}
+
+// 在后台发起请求，并等待服务开始处理该请求
+func requestInBackground(s *qhttp.Server, url string) chan string {
+	result := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			result <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		result <- string(body)
+	}()
+	for len(s.InflightRequests()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	return result
+}
+
+func TestServerShutdown(t *testing.T) {
+	s, addr := startSlowServer("server-shutdown", 200*time.Millisecond)
+	gtest.Case(t, func() {
+		result := requestInBackground(s, addr+"/slow")
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		gtest.Assert(s.Shutdown(ctx), nil)
+		gtest.Assert(<-result, "done")
+		gtest.Assert(s.Status(), qhttp.SERVER_STATUS_STOPPED)
+		gtest.Assert(len(s.InflightRequests()), 0)
+		_, err := http.Get(addr + "/slow")
+		gtest.AssertNE(err, nil)
+	})
+}
+
+func TestServerShutdownTimeout(t *testing.T) {
+	s, addr := startSlowServer("server-shutdown-timeout", time.Second)
+	gtest.Case(t, func() {
+		requestInBackground(s, addr+"/slow")
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		gtest.Assert(s.Shutdown(ctx), context.DeadlineExceeded)
+		gtest.Assert(s.Status(), qhttp.SERVER_STATUS_STOPPED)
+	})
+}
+
+func TestGetServer(t *testing.T) {
+	gtest.Case(t, func() {
+		gtest.Assert(qhttp.GetServer().GetName(), qhttp.DEFAULT_SERVER)
+		gtest.Assert(qhttp.GetServer("") == qhttp.GetServer(), true)
+		gtest.Assert(qhttp.GetServer("server-name") == qhttp.GetServer("server-name"), true)
+		gtest.Assert(qhttp.GetServer("server-name") == qhttp.GetServer(), false)
+		gtest.Assert(qhttp.GetServer("server-name").Status(), qhttp.SERVER_STATUS_STOPPED)
+	})
+}
+
+func TestServerStart(t *testing.T) {
+	s := newServer("server-start")
+	s.SetAddr("127.0.0.1:0")
+	s.BindHandler("/hello", func(r *qhttp.Request) {
+		r.Response.Write("hello ", r.GetString("name"))
+	})
+	gtest.Case(t, func() {
+		gtest.Assert(s.Start(), nil)
+		gtest.Assert(s.Status(), qhttp.SERVER_STATUS_RUNNING)
+		gtest.AssertNE(s.Start(), nil)
+		addr := "http://" + s.ListenedAddrs()[0]
+
+		resp, err := http.Get(addr + "/hello?name=john")
+		gtest.Assert(err, nil)
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		gtest.Assert(resp.StatusCode, http.StatusOK)
+		gtest.Assert(string(body), "hello john")
+
+		resp, err = http.Get(addr + "/none")
+		gtest.Assert(err, nil)
+		resp.Body.Close()
+		gtest.Assert(resp.StatusCode, http.StatusNotFound)
+
+		gtest.Assert(s.Shutdown(context.Background()), nil)
+		gtest.Assert(s.Status(), qhttp.SERVER_STATUS_STOPPED)
+		// 重复关闭不会出错
+		gtest.Assert(s.Shutdown(context.Background()), nil)
+	})
+}
+
+func TestServerRun(t *testing.T) {
+	s := newServer("server-run")
+	s.SetAddr("127.0.0.1:0")
+	gtest.Case(t, func() {
+		done := make(chan error, 1)
+		go func() {
+			done <- s.Run()
+		}()
+		for s.Status() != qhttp.SERVER_STATUS_RUNNING {
+			time.Sleep(time.Millisecond)
+		}
+		select {
+		case <-done:
+			t.Fatal("Run returned before Shutdown")
+		case <-time.After(20 * time.Millisecond):
+		}
+		gtest.Assert(s.Shutdown(context.Background()), nil)
+		gtest.Assert(<-done, nil)
+	})
+}
+
+func TestServerListenError(t *testing.T) {
+	s := newServer("server-listen-error")
+	s.SetAddr("127.0.0.1:-1")
+	gtest.Case(t, func() {
+		gtest.AssertNE(s.Start(), nil)
+		gtest.Assert(s.Status(), qhttp.SERVER_STATUS_STOPPED)
+	})
+}
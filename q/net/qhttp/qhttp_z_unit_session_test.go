@@ -0,0 +1,241 @@
+package qhttp_test
+
+import (
+	"bufio"
+	"fmt"
+	"gf/g/test/gtest"
+	"grt/q/net/qhttp"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 使用会话ID请求服务，返回内容以及返回的会话ID
+func requestSession(s *qhttp.Server, uri, id string) (string, string) {
+	cookie := ""
+	if id != "" {
+		cookie = "qsessionid=" + id
+	}
+	w := serveRequest(s, "GET", uri, "", map[string]string{"Cookie": cookie})
+	return w.Body.String(), sessionCookie(w)
+}
+
+// 获取返回的会话ID Cookie，没有创建新的会话时返回空
+func sessionCookie(w *httptest.ResponseRecorder) string {
+	for _, v := range w.Result().Cookies() {
+		if v.Name == "qsessionid" {
+			return v.Value
+		}
+	}
+	return ""
+}
+
+// 注册会话测试使用的路由
+func bindSessionHandlers(s *qhttp.Server) {
+	s.BindHandler("/get", func(r *qhttp.Request) {
+		r.Response.Write(r.Session.GetInt("count"))
+	})
+	s.BindHandler("/incr", func(r *qhttp.Request) {
+		r.Session.Set("count", r.Session.GetInt("count")+1)
+		r.Response.Write(r.Session.GetInt("count"))
+	})
+	s.BindHandler("/regenerate", func(r *qhttp.Request) {
+		r.Response.Write(r.Session.RegenerateId())
+	})
+	s.BindHandler("/destroy", func(r *qhttp.Request) {
+		r.Session.Destroy()
+	})
+}
+
+// 检查会话的完整流程
+func checkSession(s *qhttp.Server) {
+	body, id := requestSession(s, "/get", "")
+	gtest.Assert(body, "0")
+	gtest.Assert(id, "")
+
+	body, id = requestSession(s, "/incr", "")
+	gtest.Assert(body, "1")
+	gtest.Assert(len(id), 32)
+	body, _ = requestSession(s, "/incr", id)
+	gtest.Assert(body, "2")
+	body, _ = requestSession(s, "/get", id)
+	gtest.Assert(body, "2")
+
+	// 不接受服务端不存在的会话ID
+	body, newId := requestSession(s, "/incr", "unknown0session0id")
+	gtest.Assert(body, "1")
+	gtest.AssertNE(newId, "unknown0session0id")
+
+	body, _ = requestSession(s, "/regenerate", id)
+	gtest.AssertNE(body, id)
+	regenerated := body
+	body, _ = requestSession(s, "/get", id)
+	gtest.Assert(body, "0")
+	body, _ = requestSession(s, "/get", regenerated)
+	gtest.Assert(body, "2")
+
+	requestSession(s, "/destroy", regenerated)
+	body, _ = requestSession(s, "/get", regenerated)
+	gtest.Assert(body, "0")
+}
+
+func TestSessionMemory(t *testing.T) {
+	s := newServer("session-memory")
+	bindSessionHandlers(s)
+	gtest.Case(t, func() {
+		checkSession(s)
+	})
+	gtest.Case(t, func() {
+		s.SetSessionMaxAge(200 * time.Millisecond)
+		defer s.SetSessionMaxAge(time.Hour)
+		_, id := requestSession(s, "/incr", "")
+		time.Sleep(120 * time.Millisecond)
+		body, _ := requestSession(s, "/get", id)
+		gtest.Assert(body, "1")
+		time.Sleep(120 * time.Millisecond)
+		body, _ = requestSession(s, "/get", id)
+		gtest.Assert(body, "1")
+		time.Sleep(300 * time.Millisecond)
+		body, _ = requestSession(s, "/get", id)
+		gtest.Assert(body, "0")
+	})
+}
+
+func TestSessionCookieSecure(t *testing.T) {
+	s := newServer("session-secure")
+	bindSessionHandlers(s)
+	gtest.Case(t, func() {
+		for url, secure := range map[string]bool{"http://a.com/incr": false, "https://a.com/incr": true} {
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+			cookies := w.Result().Cookies()
+			gtest.Assert(len(cookies), 1)
+			gtest.Assert(cookies[0].HttpOnly, true)
+			gtest.Assert(cookies[0].Secure, secure)
+			// 会话ID只通过Cookie发送，不通过返回头暴露给页面脚本
+			gtest.Assert(w.Header().Get("qsessionid"), "")
+		}
+	})
+}
+
+func TestSessionFile(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "qhttp")
+	defer os.RemoveAll(dir)
+	storage, err := qhttp.NewSessionStorageFile(dir)
+	gtest.Assert(err, nil)
+	s := newServer("session-file")
+	s.SetSessionStorage(storage)
+	bindSessionHandlers(s)
+	gtest.Case(t, func() {
+		checkSession(s)
+	})
+}
+
+func TestSessionRedis(t *testing.T) {
+	addr, closeFunc := startRedisStub(t)
+	defer closeFunc()
+	s := newServer("session-redis")
+	s.SetSessionStorage(qhttp.NewSessionStorageRedis(qhttp.SessionRedisConfig{
+		Addr:     addr,
+		Password: "secret",
+		Db:       1,
+	}))
+	bindSessionHandlers(s)
+	gtest.Case(t, func() {
+		checkSession(s)
+	})
+	// 有效期为0时使用默认的会话有效期
+	s.SetSessionMaxAge(0)
+	gtest.Case(t, func() {
+		body, id := requestSession(s, "/incr", "")
+		gtest.Assert(body, "1")
+		body, _ = requestSession(s, "/incr", id)
+		gtest.Assert(body, "2")
+	})
+}
+
+// 启动一个兼容Redis协议的测试服务，只支持会话存储用到的命令
+func startRedisStub(t *testing.T) (string, func()) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		mu     sync.Mutex
+		values = make(map[string]string)
+	)
+	handle := func(conn net.Conn) {
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		authed := false
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+			args := make([]string, n)
+			for i := range args {
+				line, _ = reader.ReadString('\n')
+				size, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+				data := make([]byte, size+2)
+				io.ReadFull(reader, data)
+				args[i] = string(data[:size])
+			}
+			mu.Lock()
+			reply := "+OK\r\n"
+			switch strings.ToUpper(args[0]) {
+			case "AUTH":
+				if authed = args[1] == "secret"; !authed {
+					reply = "-ERR invalid password\r\n"
+				}
+			case "SELECT":
+			case "GET":
+				if v, ok := values[args[1]]; ok {
+					reply = fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+				} else {
+					reply = "$-1\r\n"
+				}
+			case "SET":
+				if ttl, _ := strconv.Atoi(args[4]); ttl <= 0 {
+					reply = "-ERR invalid expire time in 'set' command\r\n"
+				} else {
+					values[args[1]] = args[2]
+				}
+			case "PEXPIRE":
+				_, ok := values[args[1]]
+				if ttl, _ := strconv.Atoi(args[2]); ttl <= 0 {
+					delete(values, args[1])
+				}
+				reply = map[bool]string{true: ":1\r\n", false: ":0\r\n"}[ok]
+			case "DEL":
+				delete(values, args[1])
+				reply = ":1\r\n"
+			default:
+				reply = "-ERR unknown command\r\n"
+			}
+			if !authed {
+				reply = "-NOAUTH Authentication required\r\n"
+			}
+			mu.Unlock()
+			conn.Write([]byte(reply))
+		}
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go handle(conn)
+		}
+	}()
+	return ln.Addr().String(), func() { ln.Close() }
+}
@@ -0,0 +1,83 @@
+package qhttp_test
+
+import (
+	"bufio"
+	"context"
+	"gf/g/test/gtest"
+	"grt/q/net/qhttp"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSSE(t *testing.T) {
+	s := newServer("sse")
+	s.SetAddr("127.0.0.1:0")
+	finish := make(chan struct{})
+	s.BindHandler("/events", func(r *qhttp.Request) {
+		sse := r.Response.SSE()
+		defer sse.Close()
+		sse.Retry(3 * time.Second)
+		sse.SendData("resume:" + sse.LastEventId())
+		sse.Send("update", "2\n", "a\nb")
+		sse.Heartbeat(20 * time.Millisecond)
+		select {
+		case <-finish:
+		case <-sse.Done():
+		}
+	})
+	gtest.Assert(s.Start(), nil)
+	defer s.Shutdown(context.Background())
+
+	gtest.Case(t, func() {
+		req, _ := http.NewRequest("GET", "http://"+s.ListenedAddrs()[0]+"/events", nil)
+		req.Header.Set("Last-Event-ID", "1")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		gtest.Assert(resp.Header.Get("Content-Type"), "text/event-stream; charset=utf-8")
+		gtest.Assert(resp.Header.Get("Cache-Control"), "no-cache")
+
+		// 处理方法结束之前事件已经输出到客户端
+		reader := bufio.NewReader(resp.Body)
+		readEvent := func() string {
+			var lines []string
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil || line == "\n" {
+					return strings.Join(lines, "|")
+				}
+				lines = append(lines, strings.TrimSuffix(line, "\n"))
+			}
+		}
+		gtest.Assert(readEvent(), "retry: 3000")
+		gtest.Assert(readEvent(), "data: resume:1")
+		gtest.Assert(readEvent(), "id: 2|event: update|data: a|data: b")
+		gtest.Assert(readEvent(), ": heartbeat")
+		close(finish)
+	})
+}
+
+func TestSSEHeartbeatStop(t *testing.T) {
+	s := newServer("sse-heartbeat")
+	var sse *qhttp.SSE
+	// 处理方法没有关闭事件流，请求结束时自动关闭并等待心跳协程结束
+	s.BindHandler("/events", func(r *qhttp.Request) {
+		sse = r.Response.SSE()
+		sse.Heartbeat(50 * time.Microsecond)
+		time.Sleep(10 * time.Millisecond)
+	})
+	gtest.Case(t, func() {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest("GET", "/events", nil))
+		body := w.Body.String()
+		gtest.Assert(strings.HasPrefix(body, ": heartbeat\n\n"), true)
+		time.Sleep(time.Millisecond)
+		gtest.Assert(w.Body.String(), body)
+		gtest.Assert(sse.SendData("late"), qhttp.ErrSSEClosed)
+	})
+}
@@ -0,0 +1,99 @@
+package qhttp_test
+
+import (
+	"gf/g/test/gtest"
+	"grt/q/net/qhttp"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStaticFile(t *testing.T) {
+	root, _ := ioutil.TempDir("", "qhttp")
+	defer os.RemoveAll(root)
+	os.MkdirAll(filepath.Join(root, "site", "docs"), 0755)
+	os.MkdirAll(filepath.Join(root, "extra"), 0755)
+	os.MkdirAll(filepath.Join(root, "assets"), 0755)
+	ioutil.WriteFile(filepath.Join(root, "site", "index.html"), []byte("home"), 0644)
+	ioutil.WriteFile(filepath.Join(root, "site", "hello.txt"), []byte("hello world"), 0644)
+	ioutil.WriteFile(filepath.Join(root, "site", "docs", "a.txt"), []byte("a"), 0644)
+	ioutil.WriteFile(filepath.Join(root, "extra", "extra.txt"), []byte("extra"), 0644)
+	ioutil.WriteFile(filepath.Join(root, "assets", "app.js"), []byte("app"), 0644)
+	os.MkdirAll(filepath.Join(root, "site", ".git"), 0755)
+	ioutil.WriteFile(filepath.Join(root, "site", ".git", "config"), []byte("git"), 0644)
+	ioutil.WriteFile(filepath.Join(root, "site", ".env"), []byte("secret"), 0644)
+	ioutil.WriteFile(filepath.Join(root, "site", "docs", ".hidden"), []byte("hidden"), 0644)
+
+	s := newServer("static-file")
+	s.SetServerRoot(filepath.Join(root, "site"))
+	s.AddSearchPath(filepath.Join(root, "extra"))
+	s.AddStaticPath("/assets", filepath.Join(root, "assets"))
+	s.BindHandler("/hello.txt", func(r *qhttp.Request) {
+		r.Response.Write("route")
+	})
+	s.BindHandler("/api", func(r *qhttp.Request) {
+		r.Response.Write("api")
+	})
+	gtest.Case(t, func() {
+		_, body := request(s, "GET", "/")
+		gtest.Assert(body, "home")
+		_, body = request(s, "GET", "/hello.txt")
+		gtest.Assert(body, "hello world")
+		_, body = request(s, "GET", "/extra.txt")
+		gtest.Assert(body, "extra")
+		_, body = request(s, "GET", "/assets/app.js")
+		gtest.Assert(body, "app")
+		_, body = request(s, "GET", "/api")
+		gtest.Assert(body, "api")
+		code, _ := request(s, "GET", "/assets/../site/hello.txt")
+		gtest.Assert(code, http.StatusNotFound)
+		code, _ = request(s, "GET", "/docs")
+		gtest.Assert(code, http.StatusNotFound)
+
+		s.SetIndexFolder(true)
+		_, body = request(s, "GET", "/docs")
+		gtest.Assert(strings.Contains(body, `<a href="/docs/a.txt">a.txt</a>`), true)
+		gtest.Assert(strings.Contains(body, ".hidden"), false)
+
+		// 默认不允许访问隐藏文件以及目录
+		for _, uri := range []string{"/.env", "/.git/config", "/.git/", "/docs/.hidden", "/docs/../.env"} {
+			code, _ = request(s, "GET", uri)
+			gtest.Assert(code, http.StatusNotFound)
+		}
+		s.SetStaticHidden(true)
+		_, body = request(s, "GET", "/.env")
+		gtest.Assert(body, "secret")
+		_, body = request(s, "GET", "/docs")
+		gtest.Assert(strings.Contains(body, ".hidden"), true)
+		s.SetStaticHidden(false)
+
+		s.SetRouteOverStatic(true)
+		_, body = request(s, "GET", "/hello.txt")
+		gtest.Assert(body, "route")
+		s.SetRouteOverStatic(false)
+	})
+	gtest.Case(t, func() {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest("GET", "/hello.txt", nil))
+		etag := w.Header().Get("ETag")
+		gtest.AssertNE(etag, "")
+		gtest.AssertNE(w.Header().Get("Last-Modified"), "")
+
+		w = httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/hello.txt", nil)
+		req.Header.Set("If-None-Match", etag)
+		s.ServeHTTP(w, req)
+		gtest.Assert(w.Code, http.StatusNotModified)
+
+		w = httptest.NewRecorder()
+		req = httptest.NewRequest("GET", "/hello.txt", nil)
+		req.Header.Set("Range", "bytes=6-")
+		s.ServeHTTP(w, req)
+		gtest.Assert(w.Code, http.StatusPartialContent)
+		gtest.Assert(w.Body.String(), "world")
+	})
+}
@@ -0,0 +1,159 @@
+package qhttp_test
+
+import (
+	"bytes"
+	"gf/g/test/gtest"
+	"grt/q/net/qhttp"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 构造multipart上传请求，files的键为表单名称，值为"文件名称:内容"
+func uploadRequest(s *qhttp.Server, fields map[string]string, files ...[2]string) *httptest.ResponseRecorder {
+	buffer := &bytes.Buffer{}
+	writer := multipart.NewWriter(buffer)
+	for k, v := range fields {
+		writer.WriteField(k, v)
+	}
+	for _, f := range files {
+		item := strings.SplitN(f[1], ":", 2)
+		w, _ := writer.CreateFormFile(f[0], item[0])
+		w.Write([]byte(item[1]))
+	}
+	writer.Close()
+	return serveRequest(s, "POST", "/upload", buffer.String(), map[string]string{"Content-Type": writer.FormDataContentType()})
+}
+
+func TestUploadSave(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "qhttp")
+	defer os.RemoveAll(dir)
+
+	temp := ""
+	s := newServer("upload-save")
+	s.BindHandler("/upload", func(r *qhttp.Request) {
+		file := r.GetUploadFile("file")
+		name, err := file.Save(dir)
+		gtest.Assert(err, nil)
+		r.Response.Write(r.GetFormString("name"), "|", name, "|", file.Size)
+		for _, f := range r.GetUploadFiles("files") {
+			name, err := f.Save(dir, true)
+			gtest.Assert(err, nil)
+			gtest.Assert(filepath.Ext(name), ".txt")
+			gtest.AssertNE(name, f.Filename)
+			r.Response.Write("|", f.Filename)
+		}
+		f, _ := r.GetUploadFiles("files")[0].Open()
+		temp = f.Name()
+		f.Close()
+		gtest.Assert(r.GetUploadFile("none"), nil)
+	})
+	gtest.Case(t, func() {
+		w := uploadRequest(s, map[string]string{"name": "john"},
+			[2]string{"file", "../../a.txt:hello"},
+			[2]string{"files[]", "b.txt:b"},
+			[2]string{"files[]", "c.txt:c"},
+		)
+		gtest.Assert(w.Body.String(), "john|a.txt|5|b.txt|c.txt")
+		data, _ := ioutil.ReadFile(filepath.Join(dir, "a.txt"))
+		gtest.Assert(string(data), "hello")
+		files, _ := ioutil.ReadDir(dir)
+		gtest.Assert(len(files), 3)
+		// 保存过的文件不会在请求结束时被删除
+		_, err := os.Stat(temp)
+		gtest.Assert(err, nil)
+	})
+}
+
+func TestUploadSaveExists(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "qhttp")
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "a.txt"), []byte("origin"), 0644)
+
+	s := newServer("upload-exists")
+	s.BindHandler("/upload", func(r *qhttp.Request) {
+		file := r.GetUploadFile("file")
+		// 同名文件已经存在时不会被覆盖，重复保存同样不会覆盖
+		_, err := file.Save(dir)
+		r.Response.Write(os.IsExist(err))
+		name, err := file.Save(dir, true)
+		gtest.Assert(err, nil)
+		_, err = file.Save(dir, true)
+		gtest.Assert(err, nil)
+		_, err = file.Save(filepath.Join(dir, "sub"))
+		gtest.Assert(err, nil)
+		_, err = file.Save(filepath.Join(dir, "sub"))
+		r.Response.Write("|", os.IsExist(err), "|", name != "a.txt")
+	})
+	gtest.Case(t, func() {
+		w := uploadRequest(s, nil, [2]string{"file", "a.txt:hello"})
+		gtest.Assert(w.Body.String(), "true|true|true")
+		data, _ := ioutil.ReadFile(filepath.Join(dir, "a.txt"))
+		gtest.Assert(string(data), "origin")
+		data, _ = ioutil.ReadFile(filepath.Join(dir, "sub", "a.txt"))
+		gtest.Assert(string(data), "hello")
+		files, _ := ioutil.ReadDir(dir)
+		gtest.Assert(len(files), 4)
+	})
+}
+
+func TestUploadTempRemoved(t *testing.T) {
+	temp := ""
+	s := newServer("upload-temp")
+	s.BindHandler("/upload", func(r *qhttp.Request) {
+		f, _ := r.GetUploadFile("file").Open()
+		temp = f.Name()
+		f.Close()
+		r.Response.Write("ok")
+	})
+	gtest.Case(t, func() {
+		w := uploadRequest(s, nil, [2]string{"file", "a.txt:hello"})
+		gtest.Assert(w.Body.String(), "ok")
+		_, err := os.Stat(temp)
+		gtest.Assert(os.IsNotExist(err), true)
+	})
+}
+
+func TestUploadTooLarge(t *testing.T) {
+	s := newServer("upload-limit")
+	s.SetUploadMaxFileSize(4)
+	s.SetClientMaxBodySize(1024)
+	s.BindHandler("/upload", func(r *qhttp.Request) {
+		r.Response.Write("size:", r.GetUploadFile("file").Size)
+	})
+	gtest.Case(t, func() {
+		w := uploadRequest(s, nil, [2]string{"file", "a.txt:1234"})
+		gtest.Assert(w.Code, http.StatusOK)
+		gtest.Assert(w.Body.String(), "size:4")
+
+		// 单个文件超出限制
+		w = uploadRequest(s, nil, [2]string{"file", "a.txt:12345"})
+		gtest.Assert(w.Code, http.StatusRequestEntityTooLarge)
+
+		// 请求内容超出限制
+		w = uploadRequest(s, map[string]string{"name": strings.Repeat("a", 2048)}, [2]string{"file", "a.txt:1"})
+		gtest.Assert(w.Code, http.StatusRequestEntityTooLarge)
+	})
+}
+
+func TestRequestBodyTooLarge(t *testing.T) {
+	s := newServer("upload-body-limit")
+	s.SetClientMaxBodySize(8)
+	s.BindHandler("/", func(r *qhttp.Request) {
+		r.Response.Write(r.GetRawString())
+	})
+	gtest.Case(t, func() {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest("POST", "/", strings.NewReader("12345678")))
+		gtest.Assert(w.Body.String(), "12345678")
+
+		w = httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest("POST", "/", strings.NewReader("123456789")))
+		gtest.Assert(w.Code, http.StatusRequestEntityTooLarge)
+	})
+}
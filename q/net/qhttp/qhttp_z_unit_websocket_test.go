@@ -0,0 +1,257 @@
+package qhttp_test
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"context"
+	"encoding/binary"
+	"gf/g/test/gtest"
+	"grt/q/net/qhttp"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 测试使用的WebSocket客户端
+type wsClient struct {
+	conn     net.Conn
+	reader   *bufio.Reader
+	header   http.Header
+	compress bool
+}
+
+// 连接WebSocket服务，<extensions>为请求的扩展
+func dialWebSocket(t *testing.T, addr, uri, extensions string) *wsClient {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := "GET " + uri + " HTTP/1.1\r\nHost: " + addr + "\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n"
+	if extensions != "" {
+		request += "Sec-WebSocket-Extensions: " + extensions + "\r\n"
+	}
+	conn.Write([]byte(request + "\r\n"))
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &wsClient{
+		conn:     conn,
+		reader:   reader,
+		header:   resp.Header,
+		compress: strings.Contains(resp.Header.Get("Sec-WebSocket-Extensions"), "permessage-deflate"),
+	}
+}
+
+// 发送一个经过掩码处理的数据帧
+func (c *wsClient) writeFrame(fin bool, rsv1 bool, opcode int, payload []byte, masked bool) {
+	b := []byte{byte(opcode), 0}
+	if fin {
+		b[0] |= 0x80
+	}
+	if rsv1 {
+		b[0] |= 0x40
+	}
+	if masked {
+		b[1] = 0x80
+	}
+	switch {
+	case len(payload) <= 125:
+		b[1] |= byte(len(payload))
+	default:
+		b[1] |= 126
+		b = append(b, byte(len(payload)>>8), byte(len(payload)))
+	}
+	data := append([]byte(nil), payload...)
+	if masked {
+		mask := []byte{1, 2, 3, 4}
+		b = append(b, mask...)
+		for i := range data {
+			data[i] ^= mask[i%4]
+		}
+	}
+	c.conn.Write(append(b, data...))
+}
+
+// 读取一个数据帧，压缩的数据会被解压
+func (c *wsClient) readFrame() (int, []byte) {
+	c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var header [2]byte
+	if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+		return -1, nil
+	}
+	length := int(header[1] & 0x7f)
+	if length == 126 {
+		var b [2]byte
+		io.ReadFull(c.reader, b[:])
+		length = int(binary.BigEndian.Uint16(b[:]))
+	}
+	payload := make([]byte, length)
+	io.ReadFull(c.reader, payload)
+	if header[0]&0x40 != 0 {
+		reader := flate.NewReader(io.MultiReader(bytes.NewReader(payload), strings.NewReader("\x00\x00\xff\xff\x01\x00\x00\xff\xff")))
+		payload, _ = ioutil.ReadAll(reader)
+	}
+	return int(header[0] & 0x0f), payload
+}
+
+// 压缩消息内容
+func deflate(data []byte) []byte {
+	var buffer bytes.Buffer
+	w, _ := flate.NewWriter(&buffer, flate.BestSpeed)
+	w.Write(data)
+	w.Flush()
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\x00\x00\xff\xff"))
+}
+
+// 关闭消息的内容
+func closePayload(code int, text string) []byte {
+	b := make([]byte, 2)
+	binary.BigEndian.PutUint16(b, uint16(code))
+	return append(b, text...)
+}
+
+func TestWebSocket(t *testing.T) {
+	s := newServer("websocket")
+	s.SetAddr("127.0.0.1:0")
+	s.SetWebSocketCompression(true)
+	s.SetWebSocketReadLimit(1024)
+	errs := make(chan error, 10)
+	s.BindHandler("/echo", func(r *qhttp.Request) {
+		ws, err := r.WebSocket()
+		if err != nil {
+			return
+		}
+		if r.GetString("timeout") != "" {
+			ws.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+		}
+		for {
+			msgType, data, err := ws.ReadMessage()
+			if err != nil {
+				errs <- err
+				return
+			}
+			ws.WriteMessage(msgType, data)
+		}
+	})
+	gtest.Assert(s.Start(), nil)
+	defer s.Shutdown(context.Background())
+	addr := s.ListenedAddrs()[0]
+
+	gtest.Case(t, func() {
+		code, body := request(s, "GET", "/echo")
+		gtest.Assert(code, http.StatusBadRequest)
+		gtest.Assert(body, "websocket: not a websocket handshake")
+	})
+	gtest.Case(t, func() {
+		c := dialWebSocket(t, addr, "/echo", "")
+		defer c.conn.Close()
+		gtest.Assert(c.header.Get("Sec-WebSocket-Accept"), "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=")
+		gtest.Assert(c.compress, false)
+
+		c.writeFrame(true, false, qhttp.WS_MSG_TEXT, []byte("hello"), true)
+		opcode, data := c.readFrame()
+		gtest.Assert(opcode, qhttp.WS_MSG_TEXT)
+		gtest.Assert(string(data), "hello")
+
+		// 分片消息中间插入ping消息
+		c.writeFrame(false, false, qhttp.WS_MSG_BINARY, []byte("frag"), true)
+		c.writeFrame(true, false, qhttp.WS_MSG_PING, []byte("ping"), true)
+		c.writeFrame(false, false, 0, []byte("men"), true)
+		c.writeFrame(true, false, 0, []byte("ted"), true)
+		opcode, data = c.readFrame()
+		gtest.Assert(opcode, qhttp.WS_MSG_PONG)
+		gtest.Assert(string(data), "ping")
+		opcode, data = c.readFrame()
+		gtest.Assert(opcode, qhttp.WS_MSG_BINARY)
+		gtest.Assert(string(data), "fragmented")
+
+		c.writeFrame(true, false, qhttp.WS_MSG_CLOSE, closePayload(qhttp.WS_CLOSE_GOING_AWAY, "bye"), true)
+		opcode, data = c.readFrame()
+		gtest.Assert(opcode, qhttp.WS_MSG_CLOSE)
+		gtest.Assert(data, closePayload(qhttp.WS_CLOSE_GOING_AWAY, ""))
+		err := (<-errs).(*qhttp.WebSocketCloseError)
+		gtest.Assert(err.Code, qhttp.WS_CLOSE_GOING_AWAY)
+		gtest.Assert(err.Text, "bye")
+	})
+	gtest.Case(t, func() {
+		for _, v := range []struct {
+			write func(c *wsClient)
+			code  int
+		}{
+			{func(c *wsClient) { c.writeFrame(true, false, qhttp.WS_MSG_TEXT, []byte("a"), false) }, qhttp.WS_CLOSE_PROTOCOL_ERROR},
+			{func(c *wsClient) { c.writeFrame(true, false, 0, []byte("a"), true) }, qhttp.WS_CLOSE_PROTOCOL_ERROR},
+			{func(c *wsClient) { c.writeFrame(true, false, qhttp.WS_MSG_TEXT, []byte{0xff, 0xfe}, true) }, qhttp.WS_CLOSE_INVALID_PAYLOAD},
+			{func(c *wsClient) { c.writeFrame(true, false, qhttp.WS_MSG_BINARY, make([]byte, 2000), true) }, qhttp.WS_CLOSE_TOO_BIG},
+			{func(c *wsClient) { c.writeFrame(true, false, qhttp.WS_MSG_CLOSE, closePayload(1005, ""), true) }, qhttp.WS_CLOSE_PROTOCOL_ERROR},
+		} {
+			c := dialWebSocket(t, addr, "/echo", "")
+			v.write(c)
+			opcode, data := c.readFrame()
+			gtest.Assert(opcode, qhttp.WS_MSG_CLOSE)
+			gtest.Assert(int(binary.BigEndian.Uint16(data)), v.code)
+			gtest.Assert((<-errs).(*qhttp.WebSocketCloseError).Code, v.code)
+			c.conn.Close()
+		}
+	})
+	gtest.Case(t, func() {
+		c := dialWebSocket(t, addr, "/echo", "permessage-deflate; client_max_window_bits")
+		gtest.Assert(c.compress, true)
+		message := strings.Repeat("compressed ", 50)
+		c.writeFrame(true, true, qhttp.WS_MSG_TEXT, deflate([]byte(message)), true)
+		opcode, data := c.readFrame()
+		gtest.Assert(opcode, qhttp.WS_MSG_TEXT)
+		gtest.Assert(string(data), message)
+		c.conn.Close()
+		gtest.Assert(<-errs, io.EOF)
+	})
+	gtest.Case(t, func() {
+		c := dialWebSocket(t, addr, "/echo?timeout=1", "")
+		defer c.conn.Close()
+		err, ok := (<-errs).(net.Error)
+		gtest.Assert(ok && err.Timeout(), true)
+	})
+}
+
+func TestWebSocketFrameTooBig(t *testing.T) {
+	s := newServer("websocket-frame")
+	s.SetAddr("127.0.0.1:0")
+	s.SetWebSocketReadLimit(0)
+	errs := make(chan error, 1)
+	s.BindHandler("/ws", func(r *qhttp.Request) {
+		ws, err := r.WebSocket()
+		if err != nil {
+			return
+		}
+		_, _, err = ws.ReadMessage()
+		errs <- err
+	})
+	gtest.Assert(s.Start(), nil)
+	defer s.Shutdown(context.Background())
+
+	gtest.Case(t, func() {
+		// 数据帧声明了超大的长度，但是没有发送内容
+		c := dialWebSocket(t, s.ListenedAddrs()[0], "/ws", "")
+		defer c.conn.Close()
+		header := []byte{0x82, 0x80 | 127, 0, 0, 0, 0, 0, 0, 0, 0, 1, 2, 3, 4}
+		binary.BigEndian.PutUint64(header[2:10], 1<<40)
+		c.conn.Write(header)
+		opcode, data := c.readFrame()
+		gtest.Assert(opcode, qhttp.WS_MSG_CLOSE)
+		gtest.Assert(int(binary.BigEndian.Uint16(data)), qhttp.WS_CLOSE_TOO_BIG)
+		gtest.Assert((<-errs).(*qhttp.WebSocketCloseError).Code, qhttp.WS_CLOSE_TOO_BIG)
+
+		// 服务仍然可以正常处理请求
+		c2 := dialWebSocket(t, s.ListenedAddrs()[0], "/ws", "")
+		defer c2.conn.Close()
+		c2.writeFrame(true, false, qhttp.WS_MSG_TEXT, []byte("ok"), true)
+		gtest.Assert(<-errs, nil)
+	})
+}
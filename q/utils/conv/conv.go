@@ -0,0 +1,248 @@
+// Package conv提供常用类型之间的转换功能.
+package conv
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 十进制浮点数格式，例如: 1.0、-.5、1e3
+var floatRegex = regexp.MustCompile(`^[+-]?(\d+\.?\d*|\.\d+)([eE][+-]?\d+)?$`)
+
+// String将任意类型转换为字符串，复杂类型(map/slice/struct)转换为JSON字符串。
+func String(i interface{}) string {
+	if i == nil {
+		return ""
+	}
+	switch value := i.(type) {
+	case string:
+		return value
+	case []byte:
+		return string(value)
+	case bool:
+		return strconv.FormatBool(value)
+	case int:
+		return strconv.Itoa(value)
+	case int8:
+		return strconv.FormatInt(int64(value), 10)
+	case int16:
+		return strconv.FormatInt(int64(value), 10)
+	case int32:
+		return strconv.FormatInt(int64(value), 10)
+	case int64:
+		return strconv.FormatInt(value, 10)
+	case uint:
+		return strconv.FormatUint(uint64(value), 10)
+	case uint8:
+		return strconv.FormatUint(uint64(value), 10)
+	case uint16:
+		return strconv.FormatUint(uint64(value), 10)
+	case uint32:
+		return strconv.FormatUint(uint64(value), 10)
+	case uint64:
+		return strconv.FormatUint(value, 10)
+	case float32:
+		return strconv.FormatFloat(float64(value), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case json.Number:
+		return value.String()
+	case error:
+		return value.Error()
+	case fmt.Stringer:
+		return value.String()
+	}
+	rv := reflect.ValueOf(i)
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return ""
+		}
+		return String(rv.Elem().Interface())
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+		if b, err := json.Marshal(i); err == nil {
+			return string(b)
+		}
+	}
+	return fmt.Sprintf("%v", i)
+}
+
+// Int将任意类型转换为int，无法转换时返回0。
+func Int(i interface{}) int {
+	return int(Int64(i))
+}
+
+// Int64将任意类型转换为int64，无法转换时返回0。
+func Int64(i interface{}) int64 {
+	v, _ := toInt64(i)
+	return v
+}
+
+// Uint将任意类型转换为uint，无法转换时返回0。
+func Uint(i interface{}) uint {
+	return uint(Uint64(i))
+}
+
+// Uint64将任意类型转换为uint64，无法转换时返回0。
+func Uint64(i interface{}) uint64 {
+	v, _ := toUint64(i)
+	return v
+}
+
+// Float64将任意类型转换为float64，无法转换时返回0。
+func Float64(i interface{}) float64 {
+	v, _ := toFloat64(i)
+	return v
+}
+
+// Bool将任意类型转换为bool。
+// 空字符串、"0"、"false"、"off"、"no"以及数值0都被认为是false。
+func Bool(i interface{}) bool {
+	v, _ := toBool(i)
+	return v
+}
+
+// 转换为int64，并返回转换过程中的错误
+func toInt64(i interface{}) (int64, error) {
+	switch value := i.(type) {
+	case nil:
+		return 0, nil
+	case int:
+		return int64(value), nil
+	case int8:
+		return int64(value), nil
+	case int16:
+		return int64(value), nil
+	case int32:
+		return int64(value), nil
+	case int64:
+		return value, nil
+	case uint:
+		return int64(value), nil
+	case uint8:
+		return int64(value), nil
+	case uint16:
+		return int64(value), nil
+	case uint32:
+		return int64(value), nil
+	case uint64:
+		return int64(value), nil
+	case float32:
+		return floatToInt64(float64(value), String(value))
+	case float64:
+		return floatToInt64(value, String(value))
+	case bool:
+		if value {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	s := strings.TrimSpace(String(i))
+	if s == "" {
+		return 0, nil
+	}
+	// 只支持十进制，避免"010"、"0x1f"等格式被当作其他进制解析
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err == nil {
+		return v, nil
+	}
+	if e, ok := err.(*strconv.NumError); ok && e.Err == strconv.ErrRange {
+		return 0, err
+	}
+	// 兼容浮点数格式的字符串，例如: "1.0"
+	if !floatRegex.MatchString(s) {
+		return 0, fmt.Errorf(`cannot convert "%s" to integer`, s)
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	return floatToInt64(f, s)
+}
+
+// 将浮点数转换为int64，小数部分被舍去，超出int64范围时返回错误
+func floatToInt64(f float64, s string) (int64, error) {
+	if math.IsNaN(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return 0, &strconv.NumError{Func: "ParseInt", Num: s, Err: strconv.ErrRange}
+	}
+	return int64(f), nil
+}
+
+// 转换为uint64，并返回转换过程中的错误
+func toUint64(i interface{}) (uint64, error) {
+	switch value := i.(type) {
+	case uint:
+		return uint64(value), nil
+	case uint8:
+		return uint64(value), nil
+	case uint16:
+		return uint64(value), nil
+	case uint32:
+		return uint64(value), nil
+	case uint64:
+		return value, nil
+	case string:
+		// 超出int64范围的无符号整数
+		if v, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64); err == nil {
+			return v, nil
+		}
+	}
+	v, err := toInt64(i)
+	if err != nil {
+		return 0, err
+	}
+	if v < 0 {
+		return 0, fmt.Errorf(`cannot convert negative number "%d" to unsigned integer`, v)
+	}
+	return uint64(v), nil
+}
+
+// 转换为float64，并返回转换过程中的错误
+func toFloat64(i interface{}) (float64, error) {
+	switch value := i.(type) {
+	case nil:
+		return 0, nil
+	case float32:
+		return float64(value), nil
+	case float64:
+		return value, nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, bool:
+		v, err := toInt64(i)
+		return float64(v), err
+	}
+	s := strings.TrimSpace(String(i))
+	if s == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf(`cannot convert "%s" to float`, s)
+	}
+	return v, nil
+}
+
+// 转换为bool，并返回转换过程中的错误
+func toBool(i interface{}) (bool, error) {
+	switch value := i.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return value, nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		v, err := toFloat64(i)
+		return v != 0, err
+	}
+	switch s := strings.ToLower(strings.TrimSpace(String(i))); s {
+	case "", "0", "false", "off", "no":
+		return false, nil
+	case "1", "true", "on", "yes":
+		return true, nil
+	default:
+		return false, fmt.Errorf(`cannot convert "%s" to bool`, s)
+	}
+}
@@ -0,0 +1,64 @@
+package conv
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// Map将map、struct或者JSON字符串转换为map[string]interface{}，无法转换时返回nil。
+// struct的键名优先使用json标签，其次为属性名称。
+func Map(i interface{}) map[string]interface{} {
+	if i == nil {
+		return nil
+	}
+	switch value := i.(type) {
+	case map[string]interface{}:
+		return value
+	case map[string]string:
+		m := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			m[k] = v
+		}
+		return m
+	case string, []byte:
+		m := make(map[string]interface{})
+		if json.Unmarshal([]byte(String(value)), &m) != nil {
+			return nil
+		}
+		return m
+	}
+	rv := reflect.ValueOf(i)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Map:
+		m := make(map[string]interface{}, rv.Len())
+		for _, k := range rv.MapKeys() {
+			m[String(k.Interface())] = rv.MapIndex(k).Interface()
+		}
+		return m
+	case reflect.Struct:
+		m := make(map[string]interface{})
+		rt := rv.Type()
+		for k := 0; k < rv.NumField(); k++ {
+			field := rt.Field(k)
+			if field.PkgPath != "" {
+				continue
+			}
+			name := field.Name
+			if tag := strings.Split(field.Tag.Get("json"), ",")[0]; tag == "-" {
+				continue
+			} else if tag != "" {
+				name = tag
+			}
+			m[name] = rv.Field(k).Interface()
+		}
+		return m
+	}
+	return nil
+}
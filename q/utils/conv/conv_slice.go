@@ -1 +1,69 @@
 package conv
+
+import "reflect"
+
+// Strings将任意类型转换为[]string，非slice类型转换为只有一个元素的slice。
+func Strings(i interface{}) []string {
+	if i == nil {
+		return nil
+	}
+	switch value := i.(type) {
+	case []string:
+		return value
+	case []byte:
+		return []string{string(value)}
+	case []interface{}:
+		array := make([]string, len(value))
+		for k, v := range value {
+			array[k] = String(v)
+		}
+		return array
+	}
+	rv := reflect.ValueOf(i)
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		array := make([]string, rv.Len())
+		for k := 0; k < rv.Len(); k++ {
+			array[k] = String(rv.Index(k).Interface())
+		}
+		return array
+	}
+	return []string{String(i)}
+}
+
+// Ints将任意类型转换为[]int，非slice类型转换为只有一个元素的slice。
+func Ints(i interface{}) []int {
+	if i == nil {
+		return nil
+	}
+	if value, ok := i.([]int); ok {
+		return value
+	}
+	array := Interfaces(i)
+	ints := make([]int, len(array))
+	for k, v := range array {
+		ints[k] = Int(v)
+	}
+	return ints
+}
+
+// Interfaces将任意类型转换为[]interface{}，非slice类型转换为只有一个元素的slice。
+func Interfaces(i interface{}) []interface{} {
+	if i == nil {
+		return nil
+	}
+	if value, ok := i.([]interface{}); ok {
+		return value
+	}
+	rv := reflect.ValueOf(i)
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+			return []interface{}{i}
+		}
+		array := make([]interface{}, rv.Len())
+		for k := 0; k < rv.Len(); k++ {
+			array[k] = rv.Index(k).Interface()
+		}
+		return array
+	}
+	return []interface{}{i}
+}
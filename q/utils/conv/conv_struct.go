@@ -0,0 +1,293 @@
+package conv
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 结构体属性的转换错误
+type FieldError struct {
+	Field string      // 属性路径，例如: Address.City 或 Items[0].Name
+	Value interface{} // 转换失败的值
+	Err   error       // 错误信息
+}
+
+// 结构体的转换错误，包含所有转换失败的属性
+type StructError []*FieldError
+
+// 支持的时间格式
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Field, e.Err)
+}
+
+func (e StructError) Error() string {
+	array := make([]string, len(e))
+	for i, v := range e {
+		array[i] = v.Error()
+	}
+	return strings.Join(array, "; ")
+}
+
+// Struct将map参数<params>映射到结构体指针<pointer>中，支持嵌套结构体、slice以及map。
+// 参数名称优先使用标签<tagName>(默认为json)指定的名称，其次为属性名称，
+// 最后忽略大小写以及"_"、"-"进行匹配，例如: user_name 可以映射到 UserName。
+// 所有属性都会尝试转换，转换失败的属性通过StructError返回。
+func Struct(params interface{}, pointer interface{}, tagName ...string) error {
+	rv := reflect.ValueOf(pointer)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("the pointer should be a non-nil pointer to struct")
+	}
+	tag := "json"
+	if len(tagName) > 0 && tagName[0] != "" {
+		tag = tagName[0]
+	}
+	m := Map(params)
+	if m == nil {
+		return nil
+	}
+	errs := make(StructError, 0)
+	bindStruct(rv.Elem(), m, "", tag, &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// 将map绑定到结构体的各个属性
+func bindStruct(rv reflect.Value, m map[string]interface{}, path string, tag string, errs *StructError) {
+	// 用于模糊匹配的参数名称
+	fuzzy := make(map[string]string, len(m))
+	for k := range m {
+		fuzzy[fuzzyKey(k)] = k
+	}
+	rt := rv.Type()
+	for i := 0; i < rv.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(field.Tag.Get(tag), ",")[0]
+		if name == "-" {
+			continue
+		}
+		// 未指定标签的内嵌结构体使用同一层级的参数
+		if field.Anonymous && name == "" && indirectType(field.Type).Kind() == reflect.Struct {
+			fv := rv.Field(i)
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					fv.Set(reflect.New(field.Type.Elem()))
+				}
+				fv = fv.Elem()
+			}
+			bindStruct(fv, m, path, tag, errs)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		value, ok := m[name]
+		if !ok {
+			if key, found := fuzzy[fuzzyKey(name)]; found {
+				value, ok = m[key], true
+			}
+		}
+		if !ok {
+			continue
+		}
+		fieldPath := field.Name
+		if path != "" {
+			fieldPath = path + "." + field.Name
+		}
+		assign(rv.Field(i), value, fieldPath, tag, errs)
+	}
+}
+
+// 将值转换并设置到反射对象中
+func assign(v reflect.Value, value interface{}, path string, tag string, errs *StructError) {
+	fail := func(err error) {
+		*errs = append(*errs, &FieldError{Field: path, Value: value, Err: err})
+	}
+	if value == nil {
+		return
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		assign(v.Elem(), value, path, tag, errs)
+
+	case reflect.Interface:
+		if rv := reflect.ValueOf(value); rv.Type().AssignableTo(v.Type()) {
+			v.Set(rv)
+		} else {
+			fail(fmt.Errorf("cannot assign %T to %s", value, v.Type()))
+		}
+
+	case reflect.Struct:
+		if v.Type() == reflect.TypeOf(time.Time{}) {
+			t, err := toTime(value)
+			if err != nil {
+				fail(err)
+				return
+			}
+			v.Set(reflect.ValueOf(t))
+			return
+		}
+		m := Map(value)
+		if m == nil {
+			fail(fmt.Errorf("cannot convert %T to struct", value))
+			return
+		}
+		bindStruct(v, m, path, tag, errs)
+
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes([]byte(String(value)))
+			return
+		}
+		items := Interfaces(value)
+		slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			assign(slice.Index(i), item, fmt.Sprintf("%s[%d]", path, i), tag, errs)
+		}
+		v.Set(slice)
+
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			fail(fmt.Errorf("unsupported map key type %s", v.Type().Key()))
+			return
+		}
+		m := Map(value)
+		if m == nil {
+			fail(fmt.Errorf("cannot convert %T to map", value))
+			return
+		}
+		result := reflect.MakeMapWithSize(v.Type(), len(m))
+		for k, item := range m {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			assign(elem, item, path+"."+k, tag, errs)
+			result.SetMapIndex(reflect.ValueOf(k).Convert(v.Type().Key()), elem)
+		}
+		v.Set(result)
+
+	case reflect.String:
+		v.SetString(String(value))
+
+	case reflect.Bool:
+		b, err := toBool(value)
+		if err != nil {
+			fail(err)
+			return
+		}
+		v.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type() == reflect.TypeOf(time.Duration(0)) {
+			if d, err := time.ParseDuration(String(value)); err == nil {
+				v.SetInt(int64(d))
+				return
+			}
+		}
+		n, err := toInt64(value)
+		if err == nil {
+			err = checkInteger(value)
+		} else {
+			err = overflowError(err, v.Type())
+		}
+		if err == nil && v.OverflowInt(n) {
+			err = fmt.Errorf("value %d overflows %s", n, v.Type())
+		}
+		if err != nil {
+			fail(err)
+			return
+		}
+		v.SetInt(n)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := toUint64(value)
+		if err == nil {
+			err = checkInteger(value)
+		} else {
+			err = overflowError(err, v.Type())
+		}
+		if err == nil && v.OverflowUint(n) {
+			err = fmt.Errorf("value %d overflows %s", n, v.Type())
+		}
+		if err != nil {
+			fail(err)
+			return
+		}
+		v.SetUint(n)
+
+	case reflect.Float32, reflect.Float64:
+		n, err := toFloat64(value)
+		if err != nil {
+			fail(err)
+			return
+		}
+		v.SetFloat(n)
+
+	default:
+		rv := reflect.ValueOf(value)
+		if rv.Type().ConvertibleTo(v.Type()) {
+			v.Set(rv.Convert(v.Type()))
+			return
+		}
+		fail(fmt.Errorf("cannot convert %T to %s", value, v.Type()))
+	}
+}
+
+// 将值转换为时间
+func toTime(value interface{}) (time.Time, error) {
+	if t, ok := value.(time.Time); ok {
+		return t, nil
+	}
+	s := strings.TrimSpace(String(value))
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf(`cannot convert "%s" to time`, s)
+}
+
+// 获取指针指向的实际类型
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// 用于模糊匹配的名称，忽略大小写以及"_"、"-"
+func fuzzyKey(name string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(name))
+}
+
+// 检查整数字段的值，带有小数部分的值不会被截断，而是返回错误
+func checkInteger(value interface{}) error {
+	if f, err := toFloat64(value); err == nil && f != math.Trunc(f) {
+		return fmt.Errorf(`cannot convert "%s" to integer`, String(value))
+	}
+	return nil
+}
+
+// 将超出范围的转换错误转换为与属性类型相关的错误信息
+func overflowError(err error, t reflect.Type) error {
+	if e := (*strconv.NumError)(nil); errors.As(err, &e) && e.Err == strconv.ErrRange {
+		return fmt.Errorf(`value "%s" overflows %s`, e.Num, t)
+	}
+	return err
+}
@@ -0,0 +1,43 @@
+package random
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// Bytes返回<n>个直接从crypto/rand读取的随机字节，用于会话ID、令牌等安全相关的场景。
+func Bytes(n int) []byte {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// SecureStr返回包含数字和字母的随机字符串，其长度为<n>，
+// 与Str不同的是每个字符都直接来自crypto/rand并且分布均匀。
+func SecureStr(n int) string {
+	b := make([]rune, 0, n)
+	buffer := make([]byte, n)
+	for len(b) < n {
+		if _, err := rand.Read(buffer); err != nil {
+			panic(err)
+		}
+		for _, v := range buffer {
+			// 丢弃超出letters整数倍范围的值，避免取模产生偏差
+			if int(v) >= 256-256%len(letters) {
+				continue
+			}
+			b = append(b, letters[int(v)%len(letters)])
+			if len(b) == n {
+				break
+			}
+		}
+	}
+	return string(b)
+}
+
+// SecureHex返回<n>个随机字节的十六进制字符串，其长度为2*<n>。
+func SecureHex(n int) string {
+	return hex.EncodeToString(Bytes(n))
+}
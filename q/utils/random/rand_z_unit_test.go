@@ -0,0 +1,36 @@
+package random_test
+
+import (
+	"gf/g/test/gtest"
+	"grt/q/utils/random"
+	"regexp"
+	"testing"
+)
+
+func TestBytes(t *testing.T) {
+	gtest.Case(t, func() {
+		gtest.Assert(len(random.Bytes(0)), 0)
+		b := random.Bytes(32)
+		gtest.Assert(len(b), 32)
+		gtest.AssertNE(b, random.Bytes(32))
+	})
+}
+
+func TestSecureStr(t *testing.T) {
+	gtest.Case(t, func() {
+		gtest.Assert(random.SecureStr(0), "")
+		s := random.SecureStr(1000)
+		gtest.Assert(len(s), 1000)
+		gtest.Assert(regexp.MustCompile(`^[a-zA-Z0-9]+$`).MatchString(s), true)
+		gtest.AssertNE(random.SecureStr(32), random.SecureStr(32))
+	})
+}
+
+func TestSecureHex(t *testing.T) {
+	gtest.Case(t, func() {
+		s := random.SecureHex(16)
+		gtest.Assert(len(s), 32)
+		gtest.Assert(regexp.MustCompile(`^[0-9a-f]+$`).MatchString(s), true)
+		gtest.AssertNE(s, random.SecureHex(16))
+	})
+}
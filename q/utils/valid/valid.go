@@ -0,0 +1,263 @@
+// Package valid提供基于规则的数据校验功能.
+//
+// 规则格式: 规则1|规则2:参数|规则3:参数1,参数2#错误信息1|错误信息2，例如:
+// required|length:6,16|email#请输入账号|账号长度为:min到:max位|账号格式不正确
+// 只有一条自定义错误信息时，该信息用于所有规则；否则按照顺序与规则一一对应。
+// 规则与错误信息按照最后一个"#"分隔，正则规则中包含"#"但是不需要自定义错误信息时，
+// 需要在末尾添加"#"，例如: regex:^#[0-9a-f]{6}$#
+package valid
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"grt/q/utils/conv"
+)
+
+const (
+	// 结构体属性中指定校验规则的标签
+	TAG_RULES = "v"
+	// 结构体属性中指定参数名称的标签
+	TAG_PARAM = "p"
+)
+
+// 单条校验规则
+type rule struct {
+	name    string // 规则名称
+	param   string // 规则参数
+	message string // 自定义错误信息
+}
+
+// 校验单个值，错误信息中的字段名称为value，<data>为规则中引用其他字段时使用的数据，校验通过时返回nil
+func Check(value interface{}, rules string, data ...map[string]interface{}) *Error {
+	e := newError()
+	checkField("value", value, parseRules(rules), firstData(data), e)
+	if e.empty() {
+		return nil
+	}
+	return e
+}
+
+// 按照字段规则<rules>(字段 => 规则)校验map数据，校验通过时返回nil
+func CheckMap(data map[string]interface{}, rules map[string]string) *Error {
+	e := newError()
+	fields := make([]string, 0, len(rules))
+	for field := range rules {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		value, _ := lookup(data, field)
+		checkField(field, value, parseRules(rules[field]), data, e)
+	}
+	if e.empty() {
+		return nil
+	}
+	return e
+}
+
+// 按照结构体属性的v标签校验结构体，字段名称使用p标签或者属性名称。
+// 当传入请求数据<data>时，字段的值从<data>中获取，未提交的字段被认为是空值；
+// 否则直接使用结构体属性的值进行校验。校验通过时返回nil。
+func CheckStruct(object interface{}, data ...map[string]interface{}) *Error {
+	rv := reflect.ValueOf(object)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	e := newError()
+	if params := firstData(data); params != nil {
+		checkStruct(rv, params, true, "", e)
+	} else {
+		checkStruct(rv, structMap(rv), false, "", e)
+	}
+	if e.empty() {
+		return nil
+	}
+	return e
+}
+
+// 校验结构体的各个属性，嵌套的结构体字段名称使用"."连接
+func checkStruct(rv reflect.Value, params map[string]interface{}, fromData bool, prefix string, e *Error) {
+	rt := rv.Type()
+	for i := 0; i < rv.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(field.Tag.Get(TAG_PARAM), ",")[0]
+		if name == "-" {
+			continue
+		}
+		fv := reflect.Indirect(rv.Field(i))
+		if field.Anonymous && name == "" && fv.Kind() == reflect.Struct {
+			checkStruct(fv, params, fromData, prefix, e)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		var value interface{}
+		if fromData {
+			value, _ = lookup(params, name)
+		} else {
+			value = rv.Field(i).Interface()
+		}
+		if rules := field.Tag.Get(TAG_RULES); rules != "" {
+			checkField(prefix+name, value, parseRules(rules), params, e)
+		}
+		// 嵌套结构体
+		if fv.Kind() == reflect.Struct && fv.Type().PkgPath() != "time" {
+			if fromData {
+				checkStruct(fv, conv.Map(value), true, prefix+name+".", e)
+			} else {
+				checkStruct(fv, structMap(fv), false, prefix+name+".", e)
+			}
+		}
+	}
+}
+
+// 按照规则校验单个字段
+func checkField(field string, value interface{}, rules []rule, data map[string]interface{}, e *Error) {
+	if isEmpty(value) {
+		// 空值只校验required系列规则，其他规则忽略
+		for _, r := range rules {
+			if strings.HasPrefix(r.name, "required") && !callRule(r, value, data) {
+				e.add(field, r.name, errorMessage(field, r))
+			}
+		}
+		return
+	}
+	for _, r := range rules {
+		if strings.HasPrefix(r.name, "required") {
+			continue
+		}
+		if !callRule(r, value, data) {
+			e.add(field, r.name, errorMessage(field, r))
+		}
+	}
+}
+
+// 解析规则字符串
+func parseRules(text string) []rule {
+	messages := make([]string, 0)
+	// 正则规则中可能包含"#"，错误信息中不允许包含"#"
+	if index := strings.LastIndex(text, "#"); index != -1 {
+		messages = strings.Split(text[index+1:], "|")
+		text = text[:index]
+	}
+	rules := make([]rule, 0)
+	for _, item := range strings.Split(text, "|") {
+		name, param := item, ""
+		if index := strings.Index(item, ":"); index != -1 {
+			name, param = item[:index], item[index+1:]
+		}
+		name = strings.TrimSpace(name)
+		// 正则规则中可能包含"|"，不是已知规则的部分归属于上一条正则规则
+		if n := len(rules); n > 0 && strings.HasSuffix(rules[n-1].name, "regex") && !hasRule(name) {
+			rules[n-1].param += "|" + item
+			continue
+		}
+		if name != "" {
+			rules = append(rules, rule{name: name, param: param})
+		}
+	}
+	for i := range rules {
+		if len(messages) == 1 {
+			rules[i].message = messages[0]
+		} else if i < len(messages) {
+			rules[i].message = messages[i]
+		}
+	}
+	return rules
+}
+
+// 在数据中查找字段的值，支持"a.b"格式的嵌套字段，找不到时忽略大小写以及"_"、"-"进行匹配
+func lookup(data map[string]interface{}, field string) (interface{}, bool) {
+	if data == nil {
+		return nil, false
+	}
+	if v, ok := data[field]; ok {
+		return v, true
+	}
+	if index := strings.Index(field, "."); index != -1 {
+		if v, ok := lookup(data, field[:index]); ok {
+			return lookup(conv.Map(v), field[index+1:])
+		}
+		return nil, false
+	}
+	key := fuzzyKey(field)
+	for k, v := range data {
+		if fuzzyKey(k) == key {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// 将结构体转换为以参数名称为键的map
+func structMap(rv reflect.Value) map[string]interface{} {
+	m := make(map[string]interface{})
+	rt := rv.Type()
+	for i := 0; i < rv.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(field.Tag.Get(TAG_PARAM), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if fv := reflect.Indirect(rv.Field(i)); field.Anonymous && name == "" && fv.Kind() == reflect.Struct {
+			for k, v := range structMap(fv) {
+				m[k] = v
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		m[name] = rv.Field(i).Interface()
+	}
+	return m
+}
+
+// 判断值是否为空: nil、空字符串、空的slice/map以及nil指针
+func isEmpty(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	switch v := value.(type) {
+	case string:
+		return v == ""
+	case []byte:
+		return len(v) == 0
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return rv.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// 获取可选的数据参数
+func firstData(data []map[string]interface{}) map[string]interface{} {
+	if len(data) > 0 {
+		return data[0]
+	}
+	return nil
+}
+
+// 用于模糊匹配的名称，忽略大小写以及"_"、"-"
+func fuzzyKey(name string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(name))
+}
@@ -0,0 +1,99 @@
+package valid
+
+import "strings"
+
+// 校验错误，按照校验顺序记录所有失败的字段以及对应的规则。
+// 校验通过时Check等方法返回nil指针，赋值给error类型的变量之后不再等于nil，
+// 需要直接使用*Error类型判断；所有方法在nil指针上调用都是安全的，表示没有错误。
+type Error struct {
+	fields []string                     // 校验失败的字段(按照校验顺序)
+	rules  map[string][]string          // 字段校验失败的规则(按照规则顺序)
+	errors map[string]map[string]string // 字段 => 规则 => 错误信息
+}
+
+// 创建一个校验错误对象
+func newError() *Error {
+	return &Error{
+		fields: make([]string, 0),
+		rules:  make(map[string][]string),
+		errors: make(map[string]map[string]string),
+	}
+}
+
+// 添加字段校验失败的规则以及错误信息
+func (e *Error) add(field, rule, message string) {
+	if _, ok := e.errors[field]; !ok {
+		e.fields = append(e.fields, field)
+		e.errors[field] = make(map[string]string)
+	}
+	e.rules[field] = append(e.rules[field], rule)
+	e.errors[field][rule] = message
+}
+
+// 是否存在校验错误
+func (e *Error) empty() bool {
+	return e == nil || len(e.fields) == 0
+}
+
+// 获取所有校验失败的字段，按照校验顺序返回
+func (e *Error) Fields() []string {
+	if e == nil {
+		return nil
+	}
+	return append([]string(nil), e.fields...)
+}
+
+// 获取所有的错误信息，格式为: 字段 => 规则 => 错误信息
+func (e *Error) Map() map[string]map[string]string {
+	if e == nil {
+		return map[string]map[string]string{}
+	}
+	m := make(map[string]map[string]string, len(e.errors))
+	for field, rules := range e.errors {
+		m[field] = make(map[string]string, len(rules))
+		for rule, message := range rules {
+			m[field][rule] = message
+		}
+	}
+	return m
+}
+
+// 获取指定字段的错误信息，格式为: 规则 => 错误信息
+func (e *Error) FieldMap(field string) map[string]string {
+	m := make(map[string]string)
+	if e == nil {
+		return m
+	}
+	for rule, message := range e.errors[field] {
+		m[rule] = message
+	}
+	return m
+}
+
+// 获取第一条错误信息
+func (e *Error) FirstString() string {
+	if e.empty() {
+		return ""
+	}
+	field := e.fields[0]
+	return e.errors[field][e.rules[field][0]]
+}
+
+// 按照顺序获取所有的错误信息
+func (e *Error) Strings() []string {
+	array := make([]string, 0)
+	if e == nil {
+		return array
+	}
+	for _, field := range e.fields {
+		for _, rule := range e.rules[field] {
+			array = append(array, e.errors[field][rule])
+		}
+	}
+	return array
+}
+
+// 实现error接口，返回所有的错误信息
+func (e *Error) Error() string {
+	return strings.Join(e.Strings(), "; ")
+}
@@ -0,0 +1,410 @@
+package valid
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"grt/q/utils/conv"
+)
+
+// 校验规则方法，<value>为字段的值，<param>为规则参数，<data>为同一层级的所有数据，
+// 校验通过时返回true
+type RuleFunc func(value interface{}, param string, data map[string]interface{}) bool
+
+// 注册的校验规则
+type ruleItem struct {
+	fn      RuleFunc // 校验方法
+	message string   // 默认的错误信息模板
+}
+
+var (
+	// 所有的校验规则
+	ruleMapping = make(map[string]*ruleItem)
+	// 校验规则的并发控制
+	ruleMu sync.RWMutex
+	// 正则规则缓存
+	regexCache sync.Map
+)
+
+var (
+	emailRegex = regexp.MustCompile(`^[\w.%+\-]+@[A-Za-z0-9\-]+(\.[A-Za-z0-9\-]+)+$`)
+	phoneRegex = regexp.MustCompile(`^1[3-9]\d{9}$`)
+)
+
+// 日期规则支持的格式
+var dateLayouts = []string{"2006-01-02", "2006/01/02", "2006.01.02", "20060102"}
+
+func init() {
+	rules := map[string]*ruleItem{
+		"required":             {ruleRequired, "The :attribute field is required"},
+		"required-if":          {ruleRequiredIf, "The :attribute field is required"},
+		"required-unless":      {ruleRequiredUnless, "The :attribute field is required"},
+		"required-with":        {ruleRequiredWith, "The :attribute field is required"},
+		"required-with-all":    {ruleRequiredWithAll, "The :attribute field is required"},
+		"required-without":     {ruleRequiredWithout, "The :attribute field is required"},
+		"required-without-all": {ruleRequiredWithoutAll, "The :attribute field is required"},
+		"in":                   {ruleIn, "The :attribute value is not in acceptable range"},
+		"not-in":               {ruleNotIn, "The :attribute value is not in acceptable range"},
+		"between":              {ruleBetween, "The :attribute value must be between :min and :max"},
+		"min":                  {ruleMin, "The :attribute value must be equal or greater than :min"},
+		"max":                  {ruleMax, "The :attribute value must be equal or lesser than :max"},
+		"length":               {ruleLength, "The :attribute value length must be between :min and :max"},
+		"min-length":           {ruleMinLength, "The :attribute value length must be equal or greater than :min"},
+		"max-length":           {ruleMaxLength, "The :attribute value length must be equal or lesser than :max"},
+		"regex":                {ruleRegex, "The :attribute value is invalid"},
+		"not-regex":            {ruleNotRegex, "The :attribute value is invalid"},
+		"date":                 {ruleDate, "The :attribute value is not a valid date"},
+		"datetime":             {ruleDatetime, "The :attribute value is not a valid datetime"},
+		"date-format":          {ruleDateFormat, "The :attribute value does not match the format :param"},
+		"email":                {ruleEmail, "The :attribute value must be a valid email address"},
+		"phone":                {rulePhone, "The :attribute value must be a valid phone number"},
+		"url":                  {ruleUrl, "The :attribute value must be a valid URL address"},
+		"ip":                   {ruleIp, "The :attribute value must be a valid IP address"},
+		"ipv4":                 {ruleIpv4, "The :attribute value must be a valid IPv4 address"},
+		"ipv6":                 {ruleIpv6, "The :attribute value must be a valid IPv6 address"},
+		"json":                 {ruleJson, "The :attribute value must be a valid JSON string"},
+		"integer":              {ruleInteger, "The :attribute value must be an integer"},
+		"float":                {ruleFloat, "The :attribute value must be a float"},
+		"boolean":              {ruleBoolean, "The :attribute value field must be true or false"},
+		"same":                 {ruleSame, "The :attribute value must be the same as field :field"},
+		"different":            {ruleDifferent, "The :attribute value must be different from field :field"},
+	}
+	for name, item := range rules {
+		ruleMapping[name] = item
+	}
+}
+
+// 注册自定义校验规则，同名规则将会被覆盖。
+// 错误信息模板中可以使用 :attribute(字段名称)、:param(规则参数)、
+// :min/:field(第一个参数)、:max(第二个参数) 等变量。
+func RegisterRule(name string, fn RuleFunc, message ...string) {
+	item := &ruleItem{fn: fn, message: "The :attribute value is invalid"}
+	if len(message) > 0 && message[0] != "" {
+		item.message = message[0]
+	}
+	ruleMu.Lock()
+	ruleMapping[name] = item
+	ruleMu.Unlock()
+}
+
+// 判断规则是否存在
+func hasRule(name string) bool {
+	ruleMu.RLock()
+	defer ruleMu.RUnlock()
+	_, ok := ruleMapping[name]
+	return ok
+}
+
+// 执行校验规则，不存在的规则将会校验失败
+func callRule(r rule, value interface{}, data map[string]interface{}) bool {
+	ruleMu.RLock()
+	item, ok := ruleMapping[r.name]
+	ruleMu.RUnlock()
+	if !ok {
+		return false
+	}
+	return item.fn(value, r.param, data)
+}
+
+// 生成规则的错误信息
+func errorMessage(field string, r rule) string {
+	message := r.message
+	if message == "" {
+		ruleMu.RLock()
+		if item, ok := ruleMapping[r.name]; ok {
+			message = item.message
+		} else {
+			message = fmt.Sprintf("The :attribute rule %s is not defined", r.name)
+		}
+		ruleMu.RUnlock()
+	}
+	params := strings.Split(r.param, ",")
+	replaces := []string{":attribute", field, ":param", r.param, ":min", params[0], ":field", params[0]}
+	if len(params) > 1 {
+		replaces = append(replaces, ":max", params[1])
+	} else {
+		replaces = append(replaces, ":max", params[0])
+	}
+	return strings.NewReplacer(replaces...).Replace(message)
+}
+
+// 判断数据中的字段是否为空
+func fieldEmpty(data map[string]interface{}, field string) bool {
+	v, _ := lookup(data, strings.TrimSpace(field))
+	return isEmpty(v)
+}
+
+// 判断参数中的字段以及对应值是否满足，格式: field,value[,field,value...]
+func fieldsMatch(data map[string]interface{}, param string) bool {
+	params := strings.Split(param, ",")
+	for i := 0; i+1 < len(params); i += 2 {
+		v, _ := lookup(data, strings.TrimSpace(params[i]))
+		if conv.String(v) == strings.TrimSpace(params[i+1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// 获取值的长度，字符串按照字符计算，slice以及map按照元素数量计算
+func valueLength(value interface{}) int {
+	switch v := value.(type) {
+	case string:
+		return utf8.RuneCountInString(v)
+	case []interface{}:
+		return len(v)
+	case []string:
+		return len(v)
+	case map[string]interface{}:
+		return len(v)
+	}
+	return utf8.RuneCountInString(conv.String(value))
+}
+
+// 将值转换为数字
+func valueNumber(value interface{}) (float64, bool) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(conv.String(value)), 64)
+	return f, err == nil
+}
+
+// 获取编译后的正则规则
+func compileRegex(pattern string) *regexp.Regexp {
+	if v, ok := regexCache.Load(pattern); ok {
+		return v.(*regexp.Regexp)
+	}
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil
+	}
+	regexCache.Store(pattern, regex)
+	return regex
+}
+
+// required: 必须存在并且不为空
+func ruleRequired(value interface{}, param string, data map[string]interface{}) bool {
+	return !isEmpty(value)
+}
+
+// required-if:field,value,...: 任意字段等于指定值时必须存在
+func ruleRequiredIf(value interface{}, param string, data map[string]interface{}) bool {
+	return !fieldsMatch(data, param) || !isEmpty(value)
+}
+
+// required-unless:field,value,...: 所有字段都不等于指定值时必须存在
+func ruleRequiredUnless(value interface{}, param string, data map[string]interface{}) bool {
+	return fieldsMatch(data, param) || !isEmpty(value)
+}
+
+// required-with:field1,field2,...: 任意字段不为空时必须存在
+func ruleRequiredWith(value interface{}, param string, data map[string]interface{}) bool {
+	for _, field := range strings.Split(param, ",") {
+		if !fieldEmpty(data, field) {
+			return !isEmpty(value)
+		}
+	}
+	return true
+}
+
+// required-with-all:field1,field2,...: 所有字段都不为空时必须存在
+func ruleRequiredWithAll(value interface{}, param string, data map[string]interface{}) bool {
+	for _, field := range strings.Split(param, ",") {
+		if fieldEmpty(data, field) {
+			return true
+		}
+	}
+	return !isEmpty(value)
+}
+
+// required-without:field1,field2,...: 任意字段为空时必须存在
+func ruleRequiredWithout(value interface{}, param string, data map[string]interface{}) bool {
+	for _, field := range strings.Split(param, ",") {
+		if fieldEmpty(data, field) {
+			return !isEmpty(value)
+		}
+	}
+	return true
+}
+
+// required-without-all:field1,field2,...: 所有字段都为空时必须存在
+func ruleRequiredWithoutAll(value interface{}, param string, data map[string]interface{}) bool {
+	for _, field := range strings.Split(param, ",") {
+		if !fieldEmpty(data, field) {
+			return true
+		}
+	}
+	return !isEmpty(value)
+}
+
+// in:value1,value2,...: 值必须在指定的范围内
+func ruleIn(value interface{}, param string, data map[string]interface{}) bool {
+	s := conv.String(value)
+	for _, v := range strings.Split(param, ",") {
+		if strings.TrimSpace(v) == s {
+			return true
+		}
+	}
+	return false
+}
+
+// not-in:value1,value2,...: 值不能在指定的范围内
+func ruleNotIn(value interface{}, param string, data map[string]interface{}) bool {
+	return !ruleIn(value, param, data)
+}
+
+// between:min,max: 数值必须在指定的范围内
+func ruleBetween(value interface{}, param string, data map[string]interface{}) bool {
+	params := strings.Split(param, ",")
+	if len(params) != 2 {
+		return false
+	}
+	return ruleMin(value, params[0], data) && ruleMax(value, params[1], data)
+}
+
+// min:min: 数值不能小于指定值
+func ruleMin(value interface{}, param string, data map[string]interface{}) bool {
+	v, ok1 := valueNumber(value)
+	min, ok2 := valueNumber(param)
+	return ok1 && ok2 && v >= min
+}
+
+// max:max: 数值不能大于指定值
+func ruleMax(value interface{}, param string, data map[string]interface{}) bool {
+	v, ok1 := valueNumber(value)
+	max, ok2 := valueNumber(param)
+	return ok1 && ok2 && v <= max
+}
+
+// length:min,max: 长度必须在指定的范围内
+func ruleLength(value interface{}, param string, data map[string]interface{}) bool {
+	params := strings.Split(param, ",")
+	if len(params) != 2 {
+		return false
+	}
+	return ruleMinLength(value, params[0], data) && ruleMaxLength(value, params[1], data)
+}
+
+// min-length:min: 长度不能小于指定值
+func ruleMinLength(value interface{}, param string, data map[string]interface{}) bool {
+	min, err := strconv.Atoi(strings.TrimSpace(param))
+	return err == nil && valueLength(value) >= min
+}
+
+// max-length:max: 长度不能大于指定值
+func ruleMaxLength(value interface{}, param string, data map[string]interface{}) bool {
+	max, err := strconv.Atoi(strings.TrimSpace(param))
+	return err == nil && valueLength(value) <= max
+}
+
+// regex:pattern: 必须匹配正则规则
+func ruleRegex(value interface{}, param string, data map[string]interface{}) bool {
+	regex := compileRegex(param)
+	return regex != nil && regex.MatchString(conv.String(value))
+}
+
+// not-regex:pattern: 不能匹配正则规则
+func ruleNotRegex(value interface{}, param string, data map[string]interface{}) bool {
+	regex := compileRegex(param)
+	return regex != nil && !regex.MatchString(conv.String(value))
+}
+
+// date: 日期格式，例如: 2006-01-02、2006/01/02、2006.01.02、20060102
+func ruleDate(value interface{}, param string, data map[string]interface{}) bool {
+	s := conv.String(value)
+	for _, layout := range dateLayouts {
+		if _, err := time.Parse(layout, s); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// datetime: 日期时间格式，例如: 2006-01-02 15:04:05
+func ruleDatetime(value interface{}, param string, data map[string]interface{}) bool {
+	_, err := time.Parse("2006-01-02 15:04:05", conv.String(value))
+	return err == nil
+}
+
+// date-format:layout: 指定格式的日期，格式使用Go的时间模板，例如: date-format:2006-01-02
+func ruleDateFormat(value interface{}, param string, data map[string]interface{}) bool {
+	_, err := time.Parse(param, conv.String(value))
+	return err == nil
+}
+
+// email: 邮箱地址
+func ruleEmail(value interface{}, param string, data map[string]interface{}) bool {
+	return emailRegex.MatchString(conv.String(value))
+}
+
+// phone: 手机号码
+func rulePhone(value interface{}, param string, data map[string]interface{}) bool {
+	return phoneRegex.MatchString(conv.String(value))
+}
+
+// url: 带有协议以及主机的URL地址
+func ruleUrl(value interface{}, param string, data map[string]interface{}) bool {
+	u, err := url.ParseRequestURI(conv.String(value))
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+// ip: IPv4或者IPv6地址
+func ruleIp(value interface{}, param string, data map[string]interface{}) bool {
+	return net.ParseIP(conv.String(value)) != nil
+}
+
+// ipv4: IPv4地址
+func ruleIpv4(value interface{}, param string, data map[string]interface{}) bool {
+	s := conv.String(value)
+	ip := net.ParseIP(s)
+	return ip != nil && ip.To4() != nil && !strings.Contains(s, ":")
+}
+
+// ipv6: IPv6地址
+func ruleIpv6(value interface{}, param string, data map[string]interface{}) bool {
+	s := conv.String(value)
+	return net.ParseIP(s) != nil && strings.Contains(s, ":")
+}
+
+// json: JSON字符串
+func ruleJson(value interface{}, param string, data map[string]interface{}) bool {
+	return json.Valid([]byte(conv.String(value)))
+}
+
+// integer: 整数
+func ruleInteger(value interface{}, param string, data map[string]interface{}) bool {
+	_, err := strconv.ParseInt(strings.TrimSpace(conv.String(value)), 10, 64)
+	return err == nil
+}
+
+// float: 浮点数(包括整数)
+func ruleFloat(value interface{}, param string, data map[string]interface{}) bool {
+	_, ok := valueNumber(value)
+	return ok
+}
+
+// boolean: 布尔值，例如: 1、0、true、false、on、off、yes、no
+func ruleBoolean(value interface{}, param string, data map[string]interface{}) bool {
+	switch strings.ToLower(conv.String(value)) {
+	case "1", "0", "true", "false", "on", "off", "yes", "no":
+		return true
+	}
+	return false
+}
+
+// same:field: 值必须与指定字段的值相同
+func ruleSame(value interface{}, param string, data map[string]interface{}) bool {
+	v, _ := lookup(data, strings.TrimSpace(param))
+	return conv.String(value) == conv.String(v)
+}
+
+// different:field: 值必须与指定字段的值不同
+func ruleDifferent(value interface{}, param string, data map[string]interface{}) bool {
+	return !ruleSame(value, param, data)
+}
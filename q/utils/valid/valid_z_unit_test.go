@@ -0,0 +1,93 @@
+package valid_test
+
+import (
+	"gf/g/test/gtest"
+	"grt/q/utils/valid"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	gtest.Case(t, func() {
+		gtest.AssertNil(valid.Check("john@example.com", "required|email"))
+		gtest.AssertNil(valid.Check("", "email"))
+		gtest.Assert(valid.Check("", "required|email").Strings(), []string{"The value field is required"})
+		gtest.Assert(valid.Check("abc", "length:6,16#长度为:min到:max位").FirstString(), "长度为6到16位")
+		gtest.AssertNil(valid.Check("15", "between:10,20|integer"))
+		gtest.AssertNil(valid.Check("b", "in:a,b,c"))
+		gtest.AssertNil(valid.Check("2020-01-02", "date"))
+		gtest.AssertNil(valid.Check("13800138000", "phone"))
+		gtest.AssertNil(valid.Check("https://example.com/a", "url"))
+		gtest.AssertNil(valid.Check("::1", "ipv6"))
+		gtest.AssertNil(valid.Check(`{"a":1}`, "json"))
+		gtest.AssertNil(valid.Check("ab", "regex:^(ab|cd)$"))
+		gtest.AssertNE(valid.Check("ef", "regex:^(ab|cd)$|max-length:1"), nil)
+		// 正则规则中包含"#"
+		gtest.AssertNil(valid.Check("#ff00ff", "regex:^#[0-9a-f]{6}$#颜色格式不正确"))
+		gtest.Assert(valid.Check("ff00ff", "regex:^#[0-9a-f]{6}$#颜色格式不正确").FirstString(), "颜色格式不正确")
+		gtest.AssertNil(valid.Check("#ff00ff", "regex:^#[0-9a-f]{6}$#"))
+		gtest.Assert(valid.Check("ff00ff", "regex:^#[0-9a-f]{6}$#").FirstString(), "The value value is invalid")
+	})
+}
+
+func TestCheckMap(t *testing.T) {
+	gtest.Case(t, func() {
+		data := map[string]interface{}{
+			"password":  "123456",
+			"password2": "1234567",
+			"type":      "company",
+		}
+		err := valid.CheckMap(data, map[string]string{
+			"password2": "same:password",
+			"company":   "required-if:type,company",
+			"email":     "required-without:phone",
+		})
+		gtest.Assert(err.Fields(), []string{"company", "email", "password2"})
+		gtest.Assert(len(err.Map()), 3)
+	})
+}
+
+func TestCheckStruct(t *testing.T) {
+	type User struct {
+		Name     string `p:"name" v:"required|length:2,8#请输入名称|名称长度为:min到:max位"`
+		Age      int    `v:"between:1,120"`
+		Password string `v:"required"`
+	}
+	gtest.Case(t, func() {
+		err := valid.CheckStruct(&User{Name: "a", Age: 130})
+		gtest.Assert(err.Fields(), []string{"name", "Age", "Password"})
+		gtest.Assert(err.FieldMap("name")["length"], "名称长度为2到8位")
+		// 使用请求数据校验时，未提交的字段为空值
+		err = valid.CheckStruct(&User{}, map[string]interface{}{"name": "john", "password": "x"})
+		gtest.AssertNil(err)
+		err = valid.CheckStruct(&User{Name: "john"}, map[string]interface{}{"password": "x"})
+		gtest.Assert(err.FirstString(), "请输入名称")
+	})
+}
+
+func TestRegisterRule(t *testing.T) {
+	valid.RegisterRule("upper", func(value interface{}, param string, data map[string]interface{}) bool {
+		s, _ := value.(string)
+		return s == strings.ToUpper(s)
+	}, "The :attribute value must be upper case")
+	gtest.Case(t, func() {
+		gtest.AssertNil(valid.Check("ABC", "upper"))
+		err := valid.CheckMap(map[string]interface{}{"code": "abc"}, map[string]string{"code": "upper"})
+		gtest.Assert(err.Error(), "The code value must be upper case")
+	})
+}
+
+func TestErrorNil(t *testing.T) {
+	gtest.Case(t, func() {
+		err := valid.Check("john@example.com", "email")
+		gtest.Assert(err == nil, true)
+		// nil指针赋值给error之后调用方法不会panic
+		var e error = err
+		gtest.Assert(e.Error(), "")
+		gtest.Assert(err.FirstString(), "")
+		gtest.Assert(len(err.Strings()), 0)
+		gtest.Assert(len(err.Fields()), 0)
+		gtest.Assert(len(err.Map()), 0)
+		gtest.Assert(len(err.FieldMap("value")), 0)
+	})
+}